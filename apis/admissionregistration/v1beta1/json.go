@@ -0,0 +1,57 @@
+package v1beta1
+
+import (
+	"encoding/json"
+
+	"github.com/ericchiang/k8s/internal/jsonutil"
+)
+
+// RuleWithOperations inlines its Rule in JSON.
+
+type jsonRuleWithOperations RuleWithOperations
+
+func (m RuleWithOperations) MarshalJSON() ([]byte, error) {
+	j := jsonRuleWithOperations(m)
+	j.Rule = nil
+	return jsonutil.MarshalInline(j, m.Rule)
+}
+
+func (m *RuleWithOperations) UnmarshalJSON(data []byte) error {
+	var j jsonRuleWithOperations
+	inline := new(Rule)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.Rule = inline
+	}
+	*m = RuleWithOperations(j)
+	return nil
+}
+
+// Webhook configurations name their webhooks field "webhooks" rather than its
+// protobuf name. Decoding doesn't need special handling since field names are
+// matched case insensitively.
+
+type jsonMutatingWebhookConfiguration MutatingWebhookConfiguration
+
+func (m MutatingWebhookConfiguration) MarshalJSON() ([]byte, error) {
+	j := jsonMutatingWebhookConfiguration(m)
+	j.Webhooks = nil
+	return json.Marshal(struct {
+		jsonMutatingWebhookConfiguration
+		Webhooks []*Webhook `json:"webhooks,omitempty"`
+	}{j, m.Webhooks})
+}
+
+type jsonValidatingWebhookConfiguration ValidatingWebhookConfiguration
+
+func (m ValidatingWebhookConfiguration) MarshalJSON() ([]byte, error) {
+	j := jsonValidatingWebhookConfiguration(m)
+	j.Webhooks = nil
+	return json.Marshal(struct {
+		jsonValidatingWebhookConfiguration
+		Webhooks []*Webhook `json:"webhooks,omitempty"`
+	}{j, m.Webhooks})
+}
@@ -0,0 +1,127 @@
+package v1beta1
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSON marshaling logic for the types of OpenAPI schemas, so custom resource
+// definitions can be read from and written to JSON and YAML manifests.
+
+// JSON holds an arbitrary JSON value, such as a default or enum value, which is
+// encoded inline.
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j.Raw) == 0 {
+		return []byte("null"), nil
+	}
+	return j.Raw, nil
+}
+
+func (j *JSON) UnmarshalJSON(p []byte) error {
+	if string(p) == "null" {
+		j.Raw = nil
+		return nil
+	}
+	j.Raw = append(j.Raw[:0], p...)
+	return nil
+}
+
+// JSONSchemaProps names the "$schema" and "$ref" fields differently from their
+// protobuf names.
+
+type jsonSchemaProps JSONSchemaProps
+
+type jsonSchemaPropsFields struct {
+	*jsonSchemaProps
+	Schema *string `json:"$schema,omitempty"`
+	Ref    *string `json:"$ref,omitempty"`
+}
+
+func (s JSONSchemaProps) MarshalJSON() ([]byte, error) {
+	j := jsonSchemaProps(s)
+	fields := jsonSchemaPropsFields{&j, s.Schema, s.Ref}
+	j.Schema, j.Ref = nil, nil
+	return json.Marshal(fields)
+}
+
+func (s *JSONSchemaProps) UnmarshalJSON(p []byte) error {
+	var j jsonSchemaProps
+	fields := jsonSchemaPropsFields{jsonSchemaProps: &j}
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return err
+	}
+	j.Schema, j.Ref = fields.Schema, fields.Ref
+	*s = JSONSchemaProps(j)
+	return nil
+}
+
+// JSONSchemaPropsOrArray is encoded as a schema, or an array of schemas.
+
+func (s JSONSchemaPropsOrArray) MarshalJSON() ([]byte, error) {
+	if len(s.JSONSchemas) > 0 {
+		return json.Marshal(s.JSONSchemas)
+	}
+	return json.Marshal(s.Schema)
+}
+
+func (s *JSONSchemaPropsOrArray) UnmarshalJSON(p []byte) error {
+	*s = JSONSchemaPropsOrArray{}
+	switch {
+	case bytes.HasPrefix(p, []byte("{")):
+		s.Schema = new(JSONSchemaProps)
+		return json.Unmarshal(p, s.Schema)
+	case bytes.HasPrefix(p, []byte("[")):
+		return json.Unmarshal(p, &s.JSONSchemas)
+	}
+	return nil
+}
+
+// JSONSchemaPropsOrBool is encoded as a schema, or a boolean.
+
+func (s JSONSchemaPropsOrBool) MarshalJSON() ([]byte, error) {
+	if s.Schema != nil {
+		return json.Marshal(s.Schema)
+	}
+	return json.Marshal(s.GetAllows())
+}
+
+func (s *JSONSchemaPropsOrBool) UnmarshalJSON(p []byte) error {
+	*s = JSONSchemaPropsOrBool{}
+	switch {
+	case bytes.HasPrefix(p, []byte("{")):
+		allows := true
+		s.Allows = &allows
+		s.Schema = new(JSONSchemaProps)
+		return json.Unmarshal(p, s.Schema)
+	case string(p) == "null":
+		return nil
+	}
+	var allows bool
+	if err := json.Unmarshal(p, &allows); err != nil {
+		return err
+	}
+	s.Allows = &allows
+	return nil
+}
+
+// JSONSchemaPropsOrStringArray is encoded as a schema, or an array of strings.
+
+func (s JSONSchemaPropsOrStringArray) MarshalJSON() ([]byte, error) {
+	if len(s.Property) > 0 {
+		return json.Marshal(s.Property)
+	}
+	return json.Marshal(s.Schema)
+}
+
+func (s *JSONSchemaPropsOrStringArray) UnmarshalJSON(p []byte) error {
+	*s = JSONSchemaPropsOrStringArray{}
+	switch {
+	case bytes.HasPrefix(p, []byte("{")):
+		s.Schema = new(JSONSchemaProps)
+		return json.Unmarshal(p, s.Schema)
+	case bytes.HasPrefix(p, []byte("[")):
+		return json.Unmarshal(p, &s.Property)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package v1
+
+import "github.com/ericchiang/k8s/internal/jsonutil"
+
+// JSON marshaling logic for types with fields which the API server inlines,
+// such as the Handler of a Probe, so they match the API's JSON encoding.
+
+type jsonProbe Probe
+
+func (m Probe) MarshalJSON() ([]byte, error) {
+	j := jsonProbe(m)
+	j.Handler = nil
+	return jsonutil.MarshalInline(j, m.Handler)
+}
+
+func (m *Probe) UnmarshalJSON(data []byte) error {
+	var j jsonProbe
+	inline := new(Handler)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.Handler = inline
+	}
+	*m = Probe(j)
+	return nil
+}
+
+type jsonVolume Volume
+
+func (m Volume) MarshalJSON() ([]byte, error) {
+	j := jsonVolume(m)
+	j.VolumeSource = nil
+	return jsonutil.MarshalInline(j, m.VolumeSource)
+}
+
+func (m *Volume) UnmarshalJSON(data []byte) error {
+	var j jsonVolume
+	inline := new(VolumeSource)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.VolumeSource = inline
+	}
+	*m = Volume(j)
+	return nil
+}
+
+type jsonPersistentVolumeSpec PersistentVolumeSpec
+
+func (m PersistentVolumeSpec) MarshalJSON() ([]byte, error) {
+	j := jsonPersistentVolumeSpec(m)
+	j.PersistentVolumeSource = nil
+	return jsonutil.MarshalInline(j, m.PersistentVolumeSource)
+}
+
+func (m *PersistentVolumeSpec) UnmarshalJSON(data []byte) error {
+	var j jsonPersistentVolumeSpec
+	inline := new(PersistentVolumeSource)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.PersistentVolumeSource = inline
+	}
+	*m = PersistentVolumeSpec(j)
+	return nil
+}
+
+type jsonConfigMapEnvSource ConfigMapEnvSource
+
+func (m ConfigMapEnvSource) MarshalJSON() ([]byte, error) {
+	j := jsonConfigMapEnvSource(m)
+	j.LocalObjectReference = nil
+	return jsonutil.MarshalInline(j, m.LocalObjectReference)
+}
+
+func (m *ConfigMapEnvSource) UnmarshalJSON(data []byte) error {
+	var j jsonConfigMapEnvSource
+	inline := new(LocalObjectReference)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.LocalObjectReference = inline
+	}
+	*m = ConfigMapEnvSource(j)
+	return nil
+}
+
+type jsonConfigMapKeySelector ConfigMapKeySelector
+
+func (m ConfigMapKeySelector) MarshalJSON() ([]byte, error) {
+	j := jsonConfigMapKeySelector(m)
+	j.LocalObjectReference = nil
+	return jsonutil.MarshalInline(j, m.LocalObjectReference)
+}
+
+func (m *ConfigMapKeySelector) UnmarshalJSON(data []byte) error {
+	var j jsonConfigMapKeySelector
+	inline := new(LocalObjectReference)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.LocalObjectReference = inline
+	}
+	*m = ConfigMapKeySelector(j)
+	return nil
+}
+
+type jsonConfigMapProjection ConfigMapProjection
+
+func (m ConfigMapProjection) MarshalJSON() ([]byte, error) {
+	j := jsonConfigMapProjection(m)
+	j.LocalObjectReference = nil
+	return jsonutil.MarshalInline(j, m.LocalObjectReference)
+}
+
+func (m *ConfigMapProjection) UnmarshalJSON(data []byte) error {
+	var j jsonConfigMapProjection
+	inline := new(LocalObjectReference)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.LocalObjectReference = inline
+	}
+	*m = ConfigMapProjection(j)
+	return nil
+}
+
+type jsonConfigMapVolumeSource ConfigMapVolumeSource
+
+func (m ConfigMapVolumeSource) MarshalJSON() ([]byte, error) {
+	j := jsonConfigMapVolumeSource(m)
+	j.LocalObjectReference = nil
+	return jsonutil.MarshalInline(j, m.LocalObjectReference)
+}
+
+func (m *ConfigMapVolumeSource) UnmarshalJSON(data []byte) error {
+	var j jsonConfigMapVolumeSource
+	inline := new(LocalObjectReference)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.LocalObjectReference = inline
+	}
+	*m = ConfigMapVolumeSource(j)
+	return nil
+}
+
+type jsonSecretEnvSource SecretEnvSource
+
+func (m SecretEnvSource) MarshalJSON() ([]byte, error) {
+	j := jsonSecretEnvSource(m)
+	j.LocalObjectReference = nil
+	return jsonutil.MarshalInline(j, m.LocalObjectReference)
+}
+
+func (m *SecretEnvSource) UnmarshalJSON(data []byte) error {
+	var j jsonSecretEnvSource
+	inline := new(LocalObjectReference)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.LocalObjectReference = inline
+	}
+	*m = SecretEnvSource(j)
+	return nil
+}
+
+type jsonSecretKeySelector SecretKeySelector
+
+func (m SecretKeySelector) MarshalJSON() ([]byte, error) {
+	j := jsonSecretKeySelector(m)
+	j.LocalObjectReference = nil
+	return jsonutil.MarshalInline(j, m.LocalObjectReference)
+}
+
+func (m *SecretKeySelector) UnmarshalJSON(data []byte) error {
+	var j jsonSecretKeySelector
+	inline := new(LocalObjectReference)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.LocalObjectReference = inline
+	}
+	*m = SecretKeySelector(j)
+	return nil
+}
+
+type jsonSecretProjection SecretProjection
+
+func (m SecretProjection) MarshalJSON() ([]byte, error) {
+	j := jsonSecretProjection(m)
+	j.LocalObjectReference = nil
+	return jsonutil.MarshalInline(j, m.LocalObjectReference)
+}
+
+func (m *SecretProjection) UnmarshalJSON(data []byte) error {
+	var j jsonSecretProjection
+	inline := new(LocalObjectReference)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.LocalObjectReference = inline
+	}
+	*m = SecretProjection(j)
+	return nil
+}
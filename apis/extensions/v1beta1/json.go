@@ -0,0 +1,28 @@
+package v1beta1
+
+import "github.com/ericchiang/k8s/internal/jsonutil"
+
+// IngressRule inlines its IngressRuleValue in JSON, so rules are written as
+// {"host": ..., "http": ...}.
+
+type jsonIngressRule IngressRule
+
+func (m IngressRule) MarshalJSON() ([]byte, error) {
+	j := jsonIngressRule(m)
+	j.IngressRuleValue = nil
+	return jsonutil.MarshalInline(j, m.IngressRuleValue)
+}
+
+func (m *IngressRule) UnmarshalJSON(data []byte) error {
+	var j jsonIngressRule
+	inline := new(IngressRuleValue)
+	ok, err := jsonutil.UnmarshalInline(data, &j, inline)
+	if err != nil {
+		return err
+	}
+	if ok {
+		j.IngressRuleValue = inline
+	}
+	*m = IngressRule(j)
+	return nil
+}
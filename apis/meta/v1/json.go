@@ -6,28 +6,87 @@ import (
 )
 
 // JSON marshaling logic for the Time type so it can be used for custom
-// resources, which serialize to JSON.
+// resources, which serialize to JSON. Times are encoded in UTC with second
+// precision, and unset times as null, as the API server does.
 
 func (t Time) MarshalJSON() ([]byte, error) {
-	var seconds, nanos int64
-	if t.Seconds != nil {
-		seconds = *t.Seconds
+	if t.Seconds == nil && t.Nanos == nil {
+		return []byte("null"), nil
 	}
-	if t.Nanos != nil {
-		nanos = int64(*t.Nanos)
-	}
-	return json.Marshal(time.Unix(seconds, nanos))
+	return json.Marshal(unixTime(t.Seconds, t.Nanos).Format(time.RFC3339))
 }
 
 func (t *Time) UnmarshalJSON(p []byte) error {
+	if string(p) == "null" {
+		t.Seconds, t.Nanos = nil, nil
+		return nil
+	}
+	var t1 time.Time
+	if err := json.Unmarshal(p, &t1); err != nil {
+		return err
+	}
+	t.Seconds, t.Nanos = timestamp(t1)
+	return nil
+}
+
+// MicroTime is encoded like Time, but with microsecond precision.
+
+const rfc3339Micro = "2006-01-02T15:04:05.000000Z07:00"
+
+func (t MicroTime) MarshalJSON() ([]byte, error) {
+	if t.Seconds == nil && t.Nanos == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(unixTime(t.Seconds, t.Nanos).Format(rfc3339Micro))
+}
+
+func (t *MicroTime) UnmarshalJSON(p []byte) error {
+	if string(p) == "null" {
+		t.Seconds, t.Nanos = nil, nil
+		return nil
+	}
 	var t1 time.Time
 	if err := json.Unmarshal(p, &t1); err != nil {
 		return err
 	}
-	seconds := t1.Unix()
-	nanos := int32(t1.UnixNano())
-	t.Seconds = &seconds
-	t.Nanos = &nanos
+	t.Seconds, t.Nanos = timestamp(t1)
+	return nil
+}
+
+func unixTime(seconds *int64, nanos *int32) time.Time {
+	var s, n int64
+	if seconds != nil {
+		s = *seconds
+	}
+	if nanos != nil {
+		n = int64(*nanos)
+	}
+	return time.Unix(s, n).UTC()
+}
+
+func timestamp(t time.Time) (*int64, *int32) {
+	seconds := t.Unix()
+	nanos := int32(t.Nanosecond())
+	return &seconds, &nanos
+}
+
+// Duration is encoded as a string such as "1h30m".
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d.GetDuration()).String())
+}
+
+func (d *Duration) UnmarshalJSON(p []byte) error {
+	var s string
+	if err := json.Unmarshal(p, &s); err != nil {
+		return err
+	}
+	d1, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	n := int64(d1)
+	d.Duration = &n
 	return nil
 }
 
@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeJSON(t *testing.T) {
+	type times struct {
+		Time      *Time      `json:"time,omitempty"`
+		MicroTime *MicroTime `json:"microTime,omitempty"`
+		Duration  *Duration  `json:"duration,omitempty"`
+	}
+	seconds, nanos := int64(1548093845), int32(123456789)
+	d := int64(90 * time.Minute)
+	v := times{
+		Time:      &Time{Seconds: &seconds, Nanos: &nanos},
+		MicroTime: &MicroTime{Seconds: &seconds, Nanos: &nanos},
+		Duration:  &Duration{Duration: &d},
+	}
+	want := `{"time":"2019-01-21T18:04:05Z","microTime":"2019-01-21T18:04:05.123456Z","duration":"1h30m0s"}`
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("marshal: got %s, want %s", data, want)
+	}
+
+	var got times
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Time.GetSeconds() != seconds || got.Time.GetNanos() != 0 {
+		t.Errorf("unexpected time %v", got.Time)
+	}
+	if got.MicroTime.GetSeconds() != seconds || got.MicroTime.GetNanos() != 123456000 {
+		t.Errorf("unexpected micro time %v", got.MicroTime)
+	}
+	if got.Duration.GetDuration() != d {
+		t.Errorf("unexpected duration %v", got.Duration)
+	}
+
+	// Unset times are null, as the API server encodes them.
+	data, err = json.Marshal(struct{ Time Time }{})
+	if err != nil || string(data) != `{"Time":null}` {
+		t.Errorf("marshal unset time: got %s, %v", data, err)
+	}
+}
@@ -0,0 +1,447 @@
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Parsing, arithmetic and formatting for the Quantity type, which only holds
+// the quantity's string representation.
+//
+// Quantities are fixed point numbers such as "500m", "1Gi" or "2e3", with a
+// precision of 10^-9. Values with finer precision are rounded up, away from
+// zero, as the API server does.
+
+// Format is the format of a quantity's suffix. Canonicalizing a quantity keeps
+// its format, unless the value can't be represented exactly with it, such as
+// "0.5Ki" which is canonicalized as "512".
+type Format string
+
+const (
+	DecimalExponent = Format("DecimalExponent") // e.g., 12e6
+	BinarySI        = Format("BinarySI")        // e.g., 12Mi (12 * 2^20)
+	DecimalSI       = Format("DecimalSI")       // e.g., 12M  (12 * 10^6)
+)
+
+var (
+	// ErrFormatWrong is returned for strings which aren't quantities.
+	ErrFormatWrong = errors.New("quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'")
+	// ErrSuffix is returned for quantities with an unknown suffix.
+	ErrSuffix = errors.New("unable to parse quantity's suffix")
+	// ErrTooLarge is returned for quantities with an unreasonably large
+	// exponent.
+	ErrTooLarge = errors.New("quantity is too large")
+)
+
+// maxExponent bounds the decimal exponent of parsed quantities, so a string
+// such as "1e999999999" can't be used to exhaust memory.
+const maxExponent = 1000
+
+var decimalSuffixes = map[string]int{
+	"n": -9,
+	"u": -6,
+	"m": -3,
+	"":  0,
+	"k": 3,
+	"M": 6,
+	"G": 9,
+	"T": 12,
+	"P": 15,
+	"E": 18,
+}
+
+// binarySuffixes are indexed by power of 1024.
+var binarySuffixes = []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei"}
+
+var (
+	big10   = big.NewInt(10)
+	big1024 = big.NewInt(1024)
+	nano    = big.NewInt(1e9)
+)
+
+// ParseQuantity parses a quantity, returning it in canonical form. For example,
+// "1.5Gi" is canonicalized as "1536Mi", and "0.5" as "500m".
+func ParseQuantity(s string) (*Quantity, error) {
+	n, format, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return newQuantity(n, format), nil
+}
+
+// MustParse is like ParseQuantity but panics if s isn't a valid quantity. It's
+// intended for constants in code and tests.
+func MustParse(s string) *Quantity {
+	q, err := ParseQuantity(s)
+	if err != nil {
+		panic(fmt.Sprintf("cannot parse %q: %v", s, err))
+	}
+	return q
+}
+
+// NewQuantity returns a quantity with the given value.
+func NewQuantity(value int64, format Format) *Quantity {
+	n := big.NewInt(value)
+	return newQuantity(n.Mul(n, nano), format)
+}
+
+// NewMilliQuantity returns a quantity with the given value in thousandths, so
+// NewMilliQuantity(500, DecimalSI) is "500m".
+func NewMilliQuantity(milli int64, format Format) *Quantity {
+	n := big.NewInt(milli)
+	return newQuantity(n.Mul(n, big.NewInt(1e6)), format)
+}
+
+func newQuantity(nanos *big.Int, format Format) *Quantity {
+	s := formatQuantity(nanos, format)
+	return &Quantity{String_: &s}
+}
+
+// Methods treat a quantity which is unset or can't be parsed as zero. Use
+// ParseQuantity to validate strings from untrusted sources.
+
+// amount returns the quantity as a number of nano units, and its format.
+func (m *Quantity) amount() (*big.Int, Format) {
+	if m == nil || m.String_ == nil {
+		return new(big.Int), DecimalSI
+	}
+	n, format, err := parse(*m.String_)
+	if err != nil {
+		return new(big.Int), DecimalSI
+	}
+	return n, format
+}
+
+// Format returns the format of the quantity's suffix.
+func (m *Quantity) Format() Format {
+	_, format := m.amount()
+	return format
+}
+
+// IsZero reports if the quantity is zero.
+func (m *Quantity) IsZero() bool {
+	n, _ := m.amount()
+	return n.Sign() == 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the quantity.
+func (m *Quantity) Sign() int {
+	n, _ := m.amount()
+	return n.Sign()
+}
+
+// Value returns the value of the quantity, rounded up away from zero to the
+// nearest integer. Values which overflow an int64 are clamped.
+func (m *Quantity) Value() int64 {
+	n, _ := m.amount()
+	return scaledValue(n, nano)
+}
+
+// MilliValue returns the value of the quantity in thousandths, rounded up away
+// from zero. Values which overflow an int64 are clamped.
+func (m *Quantity) MilliValue() int64 {
+	n, _ := m.amount()
+	return scaledValue(n, big.NewInt(1e6))
+}
+
+// AsApproximateFloat64 returns the value of the quantity as a float64, which
+// may lose precision.
+func (m *Quantity) AsApproximateFloat64() float64 {
+	n, _ := m.amount()
+	f, _ := new(big.Rat).SetFrac(n, nano).Float64()
+	return f
+}
+
+// Cmp compares two quantities, returning -1 if m < y, 0 if m == y and +1 if
+// m > y.
+func (m *Quantity) Cmp(y *Quantity) int {
+	a, _ := m.amount()
+	b, _ := y.amount()
+	return a.Cmp(b)
+}
+
+// Equal reports if two quantities have the same value, regardless of format.
+func (m *Quantity) Equal(y *Quantity) bool {
+	return m.Cmp(y) == 0
+}
+
+// Add adds y to the quantity. The result keeps the quantity's format, or y's
+// format if the quantity is unset.
+func (m *Quantity) Add(y *Quantity) {
+	m.combine(y, (*big.Int).Add)
+}
+
+// Sub subtracts y from the quantity. The result keeps the quantity's format, or
+// y's format if the quantity is unset.
+func (m *Quantity) Sub(y *Quantity) {
+	m.combine(y, (*big.Int).Sub)
+}
+
+func (m *Quantity) combine(y *Quantity, op func(z, x, y *big.Int) *big.Int) {
+	a, format := m.amount()
+	b, yFormat := y.amount()
+	if m.String_ == nil {
+		format = yFormat
+	}
+	s := formatQuantity(op(a, a, b), format)
+	m.String_ = &s
+}
+
+// Neg negates the quantity.
+func (m *Quantity) Neg() {
+	n, format := m.amount()
+	s := formatQuantity(n.Neg(n), format)
+	m.String_ = &s
+}
+
+// Copy returns a copy of the quantity.
+func (m *Quantity) Copy() *Quantity {
+	if m == nil {
+		return nil
+	}
+	c := &Quantity{}
+	if m.String_ != nil {
+		s := *m.String_
+		c.String_ = &s
+	}
+	return c
+}
+
+// Canonical returns the canonical form of the quantity, as formatted by the
+// API server.
+func (m *Quantity) Canonical() string {
+	n, format := m.amount()
+	return formatQuantity(n, format)
+}
+
+// MarshalJSON encodes the quantity as a string, such as "500m", as the API
+// server does.
+func (m Quantity) MarshalJSON() ([]byte, error) {
+	if m.String_ == nil {
+		return []byte(`"0"`), nil
+	}
+	n, format, err := parse(*m.String_)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quantity %q: %v", *m.String_, err)
+	}
+	return json.Marshal(formatQuantity(n, format))
+}
+
+// UnmarshalJSON decodes a quantity from a string, or a number.
+func (m *Quantity) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		m.String_ = nil
+		return nil
+	}
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	} else {
+		s = string(data)
+	}
+	n, format, err := parse(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("invalid quantity %q: %v", s, err)
+	}
+	s = formatQuantity(n, format)
+	m.String_ = &s
+	return nil
+}
+
+// parse parses a quantity, returning its value in nano units.
+func parse(s string) (*big.Int, Format, error) {
+	if s == "" {
+		return nil, "", errors.New("quantities must not be empty")
+	}
+	negative := false
+	rest := s
+	switch rest[0] {
+	case '-':
+		negative = true
+		rest = rest[1:]
+	case '+':
+		rest = rest[1:]
+	}
+
+	// Split the number into its integer and fractional digits.
+	i := 0
+	for i < len(rest) && isDigit(rest[i]) {
+		i++
+	}
+	whole := rest[:i]
+	var frac string
+	if i < len(rest) && rest[i] == '.' {
+		j := i + 1
+		for j < len(rest) && isDigit(rest[j]) {
+			j++
+		}
+		frac = rest[i+1 : j]
+		i = j
+	}
+	if whole == "" && frac == "" {
+		return nil, "", ErrFormatWrong
+	}
+	suffix := rest[i:]
+
+	format, base, exponent, err := parseSuffix(suffix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	mantissa, _ := new(big.Int).SetString(whole+frac, 10)
+	if mantissa == nil {
+		mantissa = new(big.Int)
+	}
+	// value = mantissa * base^exponent * 10^-len(frac)
+	scale := 9 - len(frac) // Power of ten to convert to nano units.
+	if base == 2 {
+		mantissa.Lsh(mantissa, uint(exponent))
+	} else {
+		scale += exponent
+	}
+	if len(whole)+scale > maxExponent {
+		return nil, "", ErrTooLarge
+	}
+
+	n := mantissa
+	if scale >= 0 {
+		n.Mul(n, pow10(scale))
+	} else if -scale > len(whole)+len(frac) {
+		// The value is smaller than a nano unit, and rounds up to one.
+		if n.Sign() != 0 {
+			n.SetInt64(1)
+		}
+	} else {
+		n = divRoundUp(n, pow10(-scale))
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n, format, nil
+}
+
+// parseSuffix returns the format of a suffix, and the power of the base it
+// multiplies the number by.
+func parseSuffix(suffix string) (format Format, base, exponent int, err error) {
+	if exp, ok := decimalSuffixes[suffix]; ok {
+		return DecimalSI, 10, exp, nil
+	}
+	for i, s := range binarySuffixes {
+		if i > 0 && s == suffix {
+			return BinarySI, 2, 10 * i, nil
+		}
+	}
+	if len(suffix) > 1 && (suffix[0] == 'e' || suffix[0] == 'E') {
+		exp, err := strconv.ParseInt(suffix[1:], 10, 32)
+		if err != nil {
+			return "", 0, 0, ErrSuffix
+		}
+		if exp > maxExponent || exp < -maxExponent {
+			return "", 0, 0, ErrTooLarge
+		}
+		return DecimalExponent, 10, int(exp), nil
+	}
+	return "", 0, 0, ErrSuffix
+}
+
+// formatQuantity returns the canonical form of a value in nano units.
+func formatQuantity(nanos *big.Int, format Format) string {
+	if nanos.Sign() == 0 {
+		return "0"
+	}
+	sign := ""
+	if nanos.Sign() < 0 {
+		sign = "-"
+	}
+	abs := new(big.Int).Abs(nanos)
+
+	if format == BinarySI {
+		value, rem := new(big.Int).QuoRem(abs, nano, new(big.Int))
+		// Small and fractional values can't be represented exactly with binary
+		// suffixes.
+		if rem.Sign() != 0 || value.Cmp(big1024) < 0 {
+			format = DecimalSI
+		} else {
+			i := 0
+			for i < len(binarySuffixes)-1 {
+				q, r := new(big.Int).QuoRem(value, big1024, new(big.Int))
+				if r.Sign() != 0 {
+					break
+				}
+				value = q
+				i++
+			}
+			return sign + value.String() + binarySuffixes[i]
+		}
+	}
+
+	// Remove trailing zeros, then choose an exponent which is a multiple of
+	// three.
+	mantissa := abs
+	exponent := -9
+	for {
+		q, r := new(big.Int).QuoRem(mantissa, big10, new(big.Int))
+		if r.Sign() != 0 {
+			break
+		}
+		mantissa = q
+		exponent++
+	}
+	if rem := ((exponent % 3) + 3) % 3; rem != 0 {
+		mantissa.Mul(mantissa, pow10(rem))
+		exponent -= rem
+	}
+
+	var suffix string
+	switch {
+	case format == DecimalExponent && exponent == 0:
+	case format == DecimalExponent:
+		suffix = "e" + strconv.Itoa(exponent)
+	default:
+		suffix = "e" + strconv.Itoa(exponent)
+		for s, exp := range decimalSuffixes {
+			if exp == exponent {
+				suffix = s
+				break
+			}
+		}
+	}
+	return sign + mantissa.String() + suffix
+}
+
+// scaledValue returns n / d, rounded up away from zero and clamped to an int64.
+func scaledValue(n, d *big.Int) int64 {
+	v := divRoundUp(new(big.Int).Set(n), d)
+	switch {
+	case v.IsInt64():
+		return v.Int64()
+	case v.Sign() > 0:
+		return math.MaxInt64
+	default:
+		return math.MinInt64
+	}
+}
+
+// divRoundUp returns n / d rounded away from zero, reusing n.
+func divRoundUp(n, d *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() != 0 {
+		q.Add(q, big.NewInt(int64(n.Sign())))
+	}
+	return n.Set(q)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big10, big.NewInt(int64(n)), nil)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
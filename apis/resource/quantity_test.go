@@ -0,0 +1,185 @@
+package resource
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		// Format of the canonical form, which differs from the input's when
+		// the value can't be represented with the input's suffixes.
+		format Format
+	}{
+		{"0", "0", DecimalSI},
+		{"-0", "0", DecimalSI},
+		{"500m", "500m", DecimalSI},
+		{"0.5", "500m", DecimalSI},
+		{".5", "500m", DecimalSI},
+		{"5.", "5", DecimalSI},
+		{"+1", "1", DecimalSI},
+		{"1000", "1k", DecimalSI},
+		{"1500", "1500", DecimalSI},
+		{"1.5k", "1500", DecimalSI},
+		{"1000000m", "1k", DecimalSI},
+		{"100u", "100u", DecimalSI},
+		{"0.1n", "1n", DecimalSI},
+		{"-0.1n", "-1n", DecimalSI},
+		{"1.0000000001", "1000000001n", DecimalSI},
+		{"2E", "2E", DecimalSI},
+		{"2000E", "2e21", DecimalExponent},
+		{"1Gi", "1Gi", BinarySI},
+		{"1.5Gi", "1536Mi", BinarySI},
+		{"1024Mi", "1Gi", BinarySI},
+		{"1025Ki", "1025Ki", BinarySI},
+		{"0.5Ki", "512", DecimalSI},
+		{"0.1Ki", "102400m", DecimalSI},
+		{"-1Mi", "-1Mi", BinarySI},
+		{"2048Ei", "2048Ei", BinarySI},
+		{"2e3", "2e3", DecimalExponent},
+		{"2E3", "2e3", DecimalExponent},
+		{"1500e0", "1500", DecimalSI},
+		{"1.5e3", "1500", DecimalSI},
+		{"15e-1", "1500e-3", DecimalExponent},
+		{"1e-10", "1e-9", DecimalExponent},
+		{"12e+6", "12e6", DecimalExponent},
+	}
+	for _, test := range tests {
+		q, err := ParseQuantity(test.in)
+		if err != nil {
+			t.Errorf("parse %q: %v", test.in, err)
+			continue
+		}
+		if got := q.GetString_(); got != test.want {
+			t.Errorf("parse %q: got %q, want %q", test.in, got, test.want)
+		}
+		if got := q.Format(); got != test.format {
+			t.Errorf("parse %q: got format %s, want %s", test.in, got, test.format)
+		}
+		// The canonical form parses to itself.
+		if q2, err := ParseQuantity(test.want); err != nil || q2.GetString_() != test.want {
+			t.Errorf("canonical form %q isn't stable", test.want)
+		}
+	}
+}
+
+func TestParseQuantityErrors(t *testing.T) {
+	for _, s := range []string{"", "-", ".", "1.2.3", "1 ", " 1", "1Ki ", "1e", "1k1", "1KB", "1i", "1e1.5", "1e999999999", "m"} {
+		if q, err := ParseQuantity(s); err == nil {
+			t.Errorf("parse %q: expected error, got %q", s, q.GetString_())
+		}
+	}
+}
+
+func TestQuantityValue(t *testing.T) {
+	tests := []struct {
+		in    string
+		value int64
+		milli int64
+	}{
+		{"0", 0, 0},
+		{"1", 1, 1000},
+		{"100m", 1, 100},
+		{"1500m", 2, 1500},
+		{"-1500m", -2, -1500},
+		{"1n", 1, 1},
+		{"1Ki", 1024, 1024000},
+		{"1e3", 1000, 1000000},
+		{"10E", math.MaxInt64, math.MaxInt64},
+		{"-10E", math.MinInt64, math.MinInt64},
+	}
+	for _, test := range tests {
+		q := MustParse(test.in)
+		if got := q.Value(); got != test.value {
+			t.Errorf("%q.Value(): got %d, want %d", test.in, got, test.value)
+		}
+		if got := q.MilliValue(); got != test.milli {
+			t.Errorf("%q.MilliValue(): got %d, want %d", test.in, got, test.milli)
+		}
+	}
+}
+
+func TestQuantityArithmetic(t *testing.T) {
+	q := MustParse("1Gi")
+	q.Add(MustParse("512Mi"))
+	if got := q.GetString_(); got != "1536Mi" {
+		t.Errorf("1Gi + 512Mi: got %q", got)
+	}
+	q.Sub(MustParse("1.5Gi"))
+	if got := q.GetString_(); got != "0" || !q.IsZero() {
+		t.Errorf("1536Mi - 1.5Gi: got %q", got)
+	}
+
+	// Summing into an unset quantity takes the format of the first value.
+	var sum Quantity
+	for _, s := range []string{"250m", "500m", "1"} {
+		sum.Add(MustParse(s))
+	}
+	if got := sum.GetString_(); got != "1750m" {
+		t.Errorf("sum: got %q", got)
+	}
+
+	q = NewMilliQuantity(500, DecimalSI)
+	q.Neg()
+	if got := q.GetString_(); got != "-500m" || q.Sign() != -1 {
+		t.Errorf("neg: got %q", got)
+	}
+	if got := NewQuantity(2048, BinarySI).GetString_(); got != "2Ki" {
+		t.Errorf("NewQuantity(2048, BinarySI): got %q", got)
+	}
+	if got := MustParse("1.5").AsApproximateFloat64(); got != 1.5 {
+		t.Errorf("AsApproximateFloat64: got %v", got)
+	}
+}
+
+func TestQuantityCmp(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1Gi", "1024Mi", 0},
+		{"1G", "1Gi", -1},
+		{"1", "1000m", 0},
+		{"2e3", "2k", 0},
+		{"-1", "1n", -1},
+		{"1.5", "1499m", 1},
+	}
+	for _, test := range tests {
+		if got := MustParse(test.a).Cmp(MustParse(test.b)); got != test.want {
+			t.Errorf("cmp(%q, %q): got %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+	// Unset quantities are zero.
+	if got := new(Quantity).Cmp(MustParse("0")); got != 0 {
+		t.Errorf("cmp of unset quantity: got %d", got)
+	}
+}
+
+func TestQuantityJSON(t *testing.T) {
+	type resources struct {
+		CPU    *Quantity `json:"cpu,omitempty"`
+		Memory Quantity  `json:"memory"`
+	}
+	r := resources{CPU: MustParse("0.5"), Memory: *MustParse("1Gi")}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"cpu":"500m","memory":"1Gi"}`; string(data) != want {
+		t.Errorf("marshal: got %s, want %s", data, want)
+	}
+
+	var got resources
+	if err := json.Unmarshal([]byte(`{"cpu":1.5,"memory":"1.5Gi"}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.CPU.GetString_() != "1500m" || got.Memory.GetString_() != "1536Mi" {
+		t.Errorf("unmarshal: got cpu=%q memory=%q", got.CPU.GetString_(), got.Memory.GetString_())
+	}
+	if err := json.Unmarshal([]byte(`{"memory":"1KB"}`), &got); err == nil {
+		t.Errorf("expected error for invalid quantity")
+	}
+}
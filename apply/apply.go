@@ -0,0 +1,405 @@
+/*
+Package apply creates or updates a set of objects, such as the contents of a
+manifest bundle, in dependency order.
+
+Objects are applied one at a time. Namespaces and CustomResourceDefinitions are
+applied first, followed by RBAC rules, configuration and finally workloads.
+Custom resources are applied last, after the CustomResourceDefinitions they
+depend on have been established.
+
+	objs, err := manifest.Decode(f)
+	if err != nil {
+		// handle error
+	}
+	result, err := apply.Apply(ctx, client, objs, &apply.Options{
+		Label: "app.kubernetes.io/part-of=my-app",
+		Prune: true,
+	})
+	if err != nil {
+		// handle error
+	}
+	fmt.Println(len(result.Created), "created", len(result.Pruned), "pruned")
+
+*/
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ericchiang/k8s"
+)
+
+// installOrder is the order in which kinds are applied. Kinds not listed, such
+// as custom resources, are applied after all listed kinds.
+var installOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"PodSecurityPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"ServiceAccount",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"PriorityClass",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"Secret",
+	"ConfigMap",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"HorizontalPodAutoscaler",
+	"PodDisruptionBudget",
+	"Ingress",
+	"NetworkPolicy",
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+func installRank(kind string) int {
+	for i, k := range installOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(installOrder)
+}
+
+// Options configures an apply. A nil *Options uses the defaults described by
+// each field.
+type Options struct {
+	// Namespace is used for namespaced objects which don't specify one.
+	// Defaults to the client's namespace.
+	Namespace string
+
+	// Label is a "key=value" label added to every applied object, identifying
+	// the set of objects managed together. Required for pruning.
+	Label string
+
+	// Prune deletes objects with Label which aren't part of the applied set,
+	// such as objects removed from a manifest since it was last applied.
+	Prune bool
+
+	// PruneKinds are additional kinds checked for objects to prune. Only kinds
+	// of applied objects are checked by default, so objects of a kind removed
+	// entirely from the set aren't pruned unless listed here.
+	PruneKinds []k8s.GroupVersionKind
+
+	// Mapper resolves the resources of each kind. Defaults to a RESTMapper using
+	// an uncached discovery client.
+	Mapper *k8s.RESTMapper
+
+	// CRDTimeout is the maximum amount of time to wait for a
+	// CustomResourceDefinition to become established. Defaults to 1 minute.
+	CRDTimeout time.Duration
+
+	// PollInterval is the time between checks of a CustomResourceDefinition's
+	// status. Defaults to 1 second.
+	PollInterval time.Duration
+}
+
+func (o *Options) namespace(c *k8s.Client) string {
+	if o == nil || o.Namespace == "" {
+		return c.Namespace
+	}
+	return o.Namespace
+}
+
+func (o *Options) label() (key, val string, err error) {
+	if o == nil || o.Label == "" {
+		if o != nil && o.Prune {
+			return "", "", errors.New("pruning requires a label")
+		}
+		return "", "", nil
+	}
+	i := strings.Index(o.Label, "=")
+	if i <= 0 {
+		return "", "", fmt.Errorf("invalid label %q, expected key=value", o.Label)
+	}
+	return o.Label[:i], o.Label[i+1:], nil
+}
+
+func (o *Options) mapper(c *k8s.Client) *k8s.RESTMapper {
+	if o == nil || o.Mapper == nil {
+		return k8s.NewRESTMapper(k8s.NewDiscoveryClient(c))
+	}
+	return o.Mapper
+}
+
+func (o *Options) crdTimeout() time.Duration {
+	if o == nil || o.CRDTimeout <= 0 {
+		return time.Minute
+	}
+	return o.CRDTimeout
+}
+
+func (o *Options) pollInterval() time.Duration {
+	if o == nil || o.PollInterval <= 0 {
+		return time.Second
+	}
+	return o.PollInterval
+}
+
+// Result holds the objects changed by an apply, as returned by the API server.
+type Result struct {
+	Created []*k8s.Unstructured
+	Updated []*k8s.Unstructured
+	Pruned  []*k8s.Unstructured
+}
+
+// Sort orders objects in the order they're applied. The sort is stable, so
+// objects of the same kind keep their relative order.
+func Sort(objs []k8s.Resource) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		return installRank(kindOf(objs[i])) < installRank(kindOf(objs[j]))
+	})
+}
+
+func kindOf(r k8s.Resource) string {
+	tm, _ := k8s.TypeMetaFor(r)
+	return tm.Kind
+}
+
+// Apply creates objects that don't exist and updates those that do. Updates are
+// performed using a JSON merge patch, so fields set by the API server or other
+// clients are preserved, but fields removed from an object since it was last
+// applied aren't cleared.
+//
+// Objects can be registered types or *k8s.Unstructured values with their
+// apiVersion and kind set. The objects passed to Apply aren't modified.
+//
+// If an error occurs, the objects changed so far are returned along with the
+// error.
+func Apply(ctx context.Context, c *k8s.Client, objs []k8s.Resource, opts *Options) (*Result, error) {
+	labelKey, labelVal, err := opts.label()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]k8s.Resource, len(objs))
+	copy(sorted, objs)
+	Sort(sorted)
+
+	a := &applier{
+		dynamic:   k8s.NewDynamicClient(c),
+		mapper:    opts.mapper(c),
+		namespace: opts.namespace(c),
+		opts:      opts,
+		applied:   map[objectKey]bool{},
+	}
+	result := new(Result)
+	for _, obj := range sorted {
+		u, err := toUnstructured(obj)
+		if err != nil {
+			return result, err
+		}
+		if labelKey != "" {
+			labels := u.GetLabels()
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[labelKey] = labelVal
+			u.SetLabels(labels)
+		}
+		if err := a.apply(ctx, u, result); err != nil {
+			return result, err
+		}
+	}
+
+	if opts != nil && opts.Prune {
+		if err := a.prune(ctx, labelKey+"="+labelVal, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// objectKey identifies an object independently of the version it's served at.
+type objectKey struct {
+	group, kind, namespace, name string
+}
+
+type applier struct {
+	dynamic   *k8s.Dynamic
+	mapper    *k8s.RESTMapper
+	namespace string
+	opts      *Options
+
+	// Objects applied so far, and the kinds they belong to.
+	applied map[objectKey]bool
+	kinds   []k8s.GroupVersionKind
+}
+
+func (a *applier) apply(ctx context.Context, u *k8s.Unstructured, result *Result) error {
+	gvk := groupVersionKind(u)
+	mapping, err := a.mapper.RESTMapping(ctx, gvk)
+	if err != nil {
+		return err
+	}
+	switch {
+	case mapping.Namespaced && u.GetNamespace() == "":
+		u.SetNamespace(a.namespace)
+	case !mapping.Namespaced && u.GetNamespace() != "":
+		return fmt.Errorf("%s %s: resource not namespaced", gvk.Kind, u.GetName())
+	}
+	desc := describe(gvk.Kind, u)
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		return fmt.Errorf("encode %s: %v", desc, err)
+	}
+	err = a.dynamic.Patch(ctx, mapping.Resource, u, k8s.PatchMerge, data)
+	switch {
+	case err == nil:
+		result.Updated = append(result.Updated, u)
+	case isNotFound(err):
+		if err := a.dynamic.Create(ctx, mapping.Resource, u); err != nil {
+			return fmt.Errorf("create %s: %v", desc, err)
+		}
+		result.Created = append(result.Created, u)
+	default:
+		return fmt.Errorf("update %s: %v", desc, err)
+	}
+
+	key := objectKey{gvk.Group, gvk.Kind, u.GetNamespace(), u.GetName()}
+	if !a.applied[key] {
+		a.applied[key] = true
+		a.addKind(gvk)
+	}
+
+	if gvk.Group == "apiextensions.k8s.io" && gvk.Kind == "CustomResourceDefinition" {
+		return a.waitForEstablished(ctx, mapping.Resource, u)
+	}
+	return nil
+}
+
+func (a *applier) addKind(gvk k8s.GroupVersionKind) {
+	for _, k := range a.kinds {
+		if k == gvk {
+			return
+		}
+	}
+	a.kinds = append(a.kinds, gvk)
+}
+
+// waitForEstablished polls a CustomResourceDefinition until the API server has
+// begun serving its resource, so instances of it can be created.
+func (a *applier) waitForEstablished(ctx context.Context, gvr k8s.GroupVersionResource, crd *k8s.Unstructured) error {
+	ctx, cancel := context.WithTimeout(ctx, a.opts.crdTimeout())
+	defer cancel()
+
+	name := crd.GetName()
+	for !established(crd) {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for CustomResourceDefinition %s to be established: %v", name, ctx.Err())
+		case <-time.After(a.opts.pollInterval()):
+		}
+		crd = new(k8s.Unstructured)
+		if err := a.dynamic.Get(ctx, gvr, "", name, crd); err != nil {
+			return fmt.Errorf("get CustomResourceDefinition %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// established reports if a CustomResourceDefinition has the "Established"
+// condition.
+func established(crd *k8s.Unstructured) bool {
+	status, _ := crd.Object["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	for _, c := range conditions {
+		cond, _ := c.(map[string]interface{})
+		if cond["type"] == "Established" && cond["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// prune deletes objects matching the label selector which weren't applied.
+// Kinds are pruned in the reverse of the order they're applied, so for example
+// workloads are deleted before the namespaces that contain them.
+func (a *applier) prune(ctx context.Context, selector string, result *Result) error {
+	for _, gvk := range a.opts.PruneKinds {
+		a.addKind(gvk)
+	}
+	kinds := append([]k8s.GroupVersionKind{}, a.kinds...)
+	sort.SliceStable(kinds, func(i, j int) bool {
+		return installRank(kinds[i].Kind) > installRank(kinds[j].Kind)
+	})
+
+	for _, gvk := range kinds {
+		mapping, err := a.mapper.RESTMapping(ctx, gvk)
+		if err != nil {
+			return err
+		}
+		var list k8s.UnstructuredList
+		if err := a.dynamic.List(ctx, mapping.Resource, k8s.AllNamespaces, &list, k8s.QueryParam("labelSelector", selector)); err != nil {
+			return fmt.Errorf("list %s: %v", mapping.Resource, err)
+		}
+		for _, u := range list.Items {
+			if a.applied[objectKey{gvk.Group, gvk.Kind, u.GetNamespace(), u.GetName()}] {
+				continue
+			}
+			if err := a.dynamic.Delete(ctx, mapping.Resource, u); err != nil && !isNotFound(err) {
+				return fmt.Errorf("delete %s: %v", describe(gvk.Kind, u), err)
+			}
+			result.Pruned = append(result.Pruned, u)
+		}
+	}
+	return nil
+}
+
+// toUnstructured converts an object to a copy holding its apiVersion and kind.
+func toUnstructured(obj k8s.Resource) (*k8s.Unstructured, error) {
+	data, err := k8s.EncodeJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := new(k8s.Unstructured)
+	if err := json.Unmarshal(data, u); err != nil {
+		return nil, fmt.Errorf("decode %T: %v", obj, err)
+	}
+	if u.GetName() == "" {
+		return nil, fmt.Errorf("%s has no name", u.GetKind())
+	}
+	return u, nil
+}
+
+func groupVersionKind(u *k8s.Unstructured) k8s.GroupVersionKind {
+	apiVersion := u.GetAPIVersion()
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		return k8s.GroupVersionKind{Group: apiVersion[:i], Version: apiVersion[i+1:], Kind: u.GetKind()}
+	}
+	return k8s.GroupVersionKind{Version: apiVersion, Kind: u.GetKind()}
+}
+
+func describe(kind string, u *k8s.Unstructured) string {
+	if ns := u.GetNamespace(); ns != "" {
+		return kind + " " + ns + "/" + u.GetName()
+	}
+	return kind + " " + u.GetName()
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*k8s.APIError)
+	return ok && apiErr.Code == http.StatusNotFound
+}
@@ -0,0 +1,136 @@
+package apply
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+func newUnstructured(apiVersion, kind, name string) *k8s.Unstructured {
+	u := &k8s.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetName(name)
+	return u
+}
+
+func TestSort(t *testing.T) {
+	objs := []k8s.Resource{
+		newUnstructured("example.com/v1", "Widget", "a"),
+		newUnstructured("apps/v1", "Deployment", "b"),
+		newUnstructured("rbac.authorization.k8s.io/v1", "RoleBinding", "c"),
+		&corev1.Namespace{Metadata: &metav1.ObjectMeta{Name: k8s.String("d")}},
+		newUnstructured("apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "e"),
+		newUnstructured("example.com/v1", "Gadget", "f"),
+		newUnstructured("v1", "ServiceAccount", "g"),
+	}
+	Sort(objs)
+
+	var got []string
+	for _, obj := range objs {
+		got = append(got, kindOf(obj))
+	}
+	want := []string{
+		"Namespace",
+		"CustomResourceDefinition",
+		"ServiceAccount",
+		"RoleBinding",
+		"Deployment",
+		"Widget",
+		"Gadget",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEstablished(t *testing.T) {
+	crd := newUnstructured("apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "widgets.example.com")
+	if established(crd) {
+		t.Errorf("expected CRD without status to not be established")
+	}
+	crd.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+			map[string]interface{}{"type": "Established", "status": "False"},
+		},
+	}
+	if established(crd) {
+		t.Errorf("expected CRD with Established=False to not be established")
+	}
+	crd.Object["status"].(map[string]interface{})["conditions"].([]interface{})[1].(map[string]interface{})["status"] = "True"
+	if !established(crd) {
+		t.Errorf("expected CRD with Established=True to be established")
+	}
+}
+
+func TestOptionsLabel(t *testing.T) {
+	tests := []struct {
+		opts     *Options
+		key, val string
+		wantErr  bool
+	}{
+		{nil, "", "", false},
+		{&Options{Label: "app=foo"}, "app", "foo", false},
+		{&Options{Label: "app="}, "app", "", false},
+		{&Options{Label: "app"}, "", "", true},
+		{&Options{Label: "=foo"}, "", "", true},
+		{&Options{Prune: true}, "", "", true},
+	}
+	for _, test := range tests {
+		key, val, err := test.opts.label()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%+v: wanted error=%t, got %v", test.opts, test.wantErr, err)
+			continue
+		}
+		if key != test.key || val != test.val {
+			t.Errorf("%+v: got %q=%q, want %q=%q", test.opts, key, val, test.key, test.val)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	configMap := func(name, val string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			Metadata: &metav1.ObjectMeta{Name: k8s.String(name)},
+			Data:     map[string]string{"key": val},
+		}
+	}
+	opts := &Options{Label: "app=test", Prune: true}
+
+	result, err := Apply(ctx, client, []k8s.Resource{configMap("a", "1"), configMap("b", "1")}, opts)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(result.Created) != 2 || len(result.Updated) != 0 || len(result.Pruned) != 0 {
+		t.Errorf("expected 2 objects created, got %d created, %d updated, %d pruned",
+			len(result.Created), len(result.Updated), len(result.Pruned))
+	}
+
+	result, err = Apply(ctx, client, []k8s.Resource{configMap("a", "2")}, opts)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(result.Created) != 0 || len(result.Updated) != 1 || len(result.Pruned) != 1 {
+		t.Errorf("expected 1 object updated and 1 pruned, got %d created, %d updated, %d pruned",
+			len(result.Created), len(result.Updated), len(result.Pruned))
+	}
+
+	var got corev1.ConfigMapList
+	if err := client.List(ctx, "default", &got); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(got.Items) != 1 || got.Items[0].Data["key"] != "2" || got.Items[0].Metadata.Labels["app"] != "test" {
+		t.Errorf("unexpected config maps after apply: %v", got.Items)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -38,11 +39,14 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"reflect"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/http2"
 
 	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/internal/retry"
 )
 
 const (
@@ -117,7 +121,36 @@ type Client struct {
 	//
 	SetHeaders func(h http.Header) error
 
+	// Hooks are invoked after each request completes, for example to log
+	// requests or collect metrics. See the metrics package for a Prometheus
+	// collector.
+	//
+	//		collector := metrics.NewCollector()
+	//		client.Hooks = append(client.Hooks, collector)
+	//		http.Handle("/metrics", collector)
+	//
+	Hooks []RequestHook
+
+	// ContentType is the encoding used for generated types, which is protobuf
+	// by default. Set it to ContentTypeJSON to use JSON instead, for example to
+	// debug requests or talk to aggregated API servers which don't support
+	// protobuf. Requests which the API server rejects because of their content
+	// type are retried using JSON automatically.
+	//
+	// Types which aren't generated, such as custom resources, always use JSON.
+	ContentType string
+
+	// Tracer, if set, starts a span for each request. Requests carry a
+	// traceparent header from the span, or from a span context in the request's
+	// context if there's no Tracer.
+	Tracer Tracer
+
 	Client *http.Client
+
+	// Discovery client caching responses in memory, created on first use by
+	// methods which need discovery information, such as UpdateStatus. Holds a
+	// *Discovery.
+	cachedDiscovery atomic.Value
 }
 
 func (c *Client) newRequest(ctx context.Context, verb, url string, body io.Reader) (*http.Request, error) {
@@ -399,7 +432,7 @@ func (c *Client) Create(ctx context.Context, req Resource, options ...Option) er
 	if err != nil {
 		return err
 	}
-	return c.do(ctx, "POST", url, req, req)
+	return c.do(ctx, "POST", url, req, req, options...)
 }
 
 func (c *Client) Delete(ctx context.Context, req Resource, options ...Option) error {
@@ -419,58 +452,409 @@ func (c *Client) Delete(ctx context.Context, req Resource, options ...Option) er
 	return c.do(ctx, "DELETE", url, o, nil)
 }
 
+// DeleteAndWait deletes a resource and waits until it's been removed, or the
+// timeout expires. Resources with finalizers, or deleted with
+// DeletePropagationForeground(), are only removed by the API server once their
+// finalizers have run, which may take a while.
+//
+// The deletion is tracked by UID, so a resource recreated with the same name
+// doesn't prevent DeleteAndWait from returning. If r doesn't have a UID, the
+// resource is fetched first. A timeout of zero waits until ctx is canceled.
+//
+//		err := client.DeleteAndWait(ctx, deployment, time.Minute, k8s.DeletePropagationForeground())
+//
+func (c *Client) DeleteAndWait(ctx context.Context, r Resource, timeout time.Duration, options ...Option) error {
+	return DeleteAndWait(ctx, c, r, timeout, options...)
+}
+
+// DeleteAndWait is like Client.DeleteAndWait, but deletes, gets and watches the
+// resource through c, so it can be used with decorators of a client.
+//
+//		err := k8s.DeleteAndWait(ctx, loggingClient{client}, deployment, time.Minute)
+//
+func DeleteAndWait(ctx context.Context, c Interface, r Resource, timeout time.Duration, options ...Option) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	d := &deletion{c: c, r: r, uid: r.GetMetadata().GetUid()}
+	if d.uid == "" {
+		current, err := d.get(ctx)
+		if err != nil || current == nil {
+			return d.err(ctx, err)
+		}
+		d.uid = current.GetMetadata().GetUid()
+	}
+
+	err := c.Delete(ctx, r, append(options[:len(options):len(options)], DeletePreconditionUID(d.uid))...)
+	if apiErr, ok := err.(*APIError); ok && apiErr.Code == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, option := range options {
+		if _, ok := option.(dryRunOption); ok {
+			return nil
+		}
+	}
+
+	for {
+		gone, err := d.wait(ctx)
+		if err != nil || gone {
+			return d.err(ctx, err)
+		}
+		// The watch ended before the resource was removed. Start a new one
+		// after a delay, so a watch which keeps failing doesn't turn into a
+		// tight loop of requests.
+		if err := d.backoff.Wait(ctx); err != nil {
+			return d.err(ctx, err)
+		}
+	}
+}
+
+// deletion tracks the deletion of a resource by its UID.
+type deletion struct {
+	c   Interface
+	r   Resource
+	uid string
+
+	backoff retry.Backoff
+}
+
+func (d *deletion) newResource() Resource {
+	return reflect.New(reflect.TypeOf(d.r).Elem()).Interface().(Resource)
+}
+
+// get returns the current version of the resource, or nil if it's gone.
+func (d *deletion) get(ctx context.Context) (Resource, error) {
+	meta := d.r.GetMetadata()
+	current := d.newResource()
+	err := d.c.Get(ctx, meta.GetNamespace(), meta.GetName(), current)
+	if apiErr, ok := err.(*APIError); ok && apiErr.Code == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.uid != "" && current.GetMetadata().GetUid() != d.uid {
+		return nil, nil
+	}
+	return current, nil
+}
+
+// wait watches the resource, returning true once it's gone, or false if the
+// watch ends or fails with a retryable error first. The resource is checked
+// after the watch is established, so a removal between the two is never missed.
+func (d *deletion) wait(ctx context.Context) (bool, error) {
+	meta := d.r.GetMetadata()
+	w, err := d.c.Watch(ctx, meta.GetNamespace(), d.newResource(),
+		QueryParam("fieldSelector", "metadata.name="+meta.GetName()))
+	if err != nil {
+		return false, err
+	}
+	defer w.Close()
+
+	current, err := d.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if current == nil {
+		return true, nil
+	}
+	for {
+		event := d.newResource()
+		eventType, err := w.Next(event)
+		if err != nil {
+			if apiErr, ok := err.(*APIError); ok && !retry.Retryable(apiErr.Code) {
+				return false, err
+			}
+			// The watch ended or failed, the caller starts a new one.
+			return false, nil
+		}
+		if eventType == EventDeleted || event.GetMetadata().GetUid() != d.uid {
+			return true, nil
+		}
+		d.backoff.Reset()
+	}
+}
+
+// err prefers the context's error, so requests failing due to the timeout
+// report the timeout.
+func (d *deletion) err(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("waiting for %s to be deleted: %v", d.r.GetMetadata().GetName(), ctx.Err())
+	}
+	return err
+}
+
+// DeleteCollection deletes all resources of the list's type in a namespace, or
+// only those matching label and field selectors. Delete options, such as
+// DeletePropagationForeground() or DeleteGracePeriod(), apply to each deleted
+// resource.
+//
+// Depending on its version, the API server either responds with the deleted
+// resources, which are decoded into list, or with a status. The status is
+// returned if sent, otherwise a successful status is returned.
+//
+//		l := new(k8s.LabelSelector)
+//		l.Eq("app", "my-app")
+//		fields := k8s.QueryParam("fieldSelector", "status.phase=Succeeded")
+//
+//		var pods corev1.PodList
+//		if _, err := client.DeleteCollection(ctx, "my-namespace", &pods, l.Selector(), fields); err != nil {
+//			// handle error
+//		}
+//
+func (c *Client) DeleteCollection(ctx context.Context, namespace string, list ResourceList, options ...Option) (*metav1.Status, error) {
+	url, err := resourceListURL(c.Endpoint, namespace, list, options...)
+	if err != nil {
+		return nil, err
+	}
+	o := &deleteOptions{
+		Kind:              "DeleteOptions",
+		APIVersion:        "v1",
+		PropagationPolicy: "Background",
+	}
+	for _, option := range options {
+		option.updateDelete(nil, o)
+	}
+
+	resp := &statusOrList{list: list}
+	if err := c.do(ctx, "DELETE", url, o, resp, options...); err != nil {
+		return nil, err
+	}
+	if resp.status == nil {
+		return &metav1.Status{Status: String("Success")}, nil
+	}
+	return resp.status, nil
+}
+
+// statusOrList decodes a JSON response which is either a status or a list.
+type statusOrList struct {
+	status *metav1.Status
+	list   ResourceList
+}
+
+func (s *statusOrList) UnmarshalJSON(data []byte) error {
+	var tm TypeMeta
+	if err := json.Unmarshal(data, &tm); err != nil {
+		return err
+	}
+	if tm.Kind == "Status" {
+		s.status = new(metav1.Status)
+		return json.Unmarshal(data, s.status)
+	}
+	return json.Unmarshal(data, s.list)
+}
+
+type eviction struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	DeleteOptions *deleteOptions `json:"deleteOptions,omitempty"`
+}
+
+// Evict deletes a pod through the "pods/eviction" subresource. Unlike Delete,
+// evictions respect PodDisruptionBudgets. If an eviction would violate a budget
+// the API server responds with a 429 status code, and the eviction should be
+// retried later.
+//
+// A negative grace period uses the pod's default termination grace period.
+// Delete options such as DeleteAtomic() may also be passed.
+//
+//		pod := new(corev1.Pod)
+//		if err := client.Get(ctx, "my-namespace", "my-pod", pod); err != nil {
+//			// handle error
+//		}
+//		if err := client.Evict(ctx, pod, 30*time.Second); err != nil {
+//			if apiErr, ok := err.(*k8s.APIError); ok && apiErr.Code == http.StatusTooManyRequests {
+//				// disruption budget doesn't allow eviction, try again later
+//			}
+//			// handle error
+//		}
+//
+func (c *Client) Evict(ctx context.Context, pod Resource, gracePeriod time.Duration, options ...Option) error {
+	if t, ok := resources[reflect.TypeOf(pod)]; !ok || t.apiGroup != "" || t.name != "pods" {
+		return fmt.Errorf("type %T is not a registered pod type", pod)
+	}
+	url, err := resourceURL(c.Endpoint, pod, true, append(options[:len(options):len(options)], Subresource("eviction"))...)
+	if err != nil {
+		return err
+	}
+	o := &deleteOptions{
+		Kind:              "DeleteOptions",
+		APIVersion:        "v1",
+		PropagationPolicy: "Background",
+	}
+	if gracePeriod >= 0 {
+		seconds := int64(gracePeriod / time.Second)
+		o.GracePeriod = &seconds
+	}
+	for _, option := range options {
+		option.updateDelete(pod, o)
+	}
+
+	e := &eviction{
+		Kind:          "Eviction",
+		APIVersion:    "policy/v1beta1",
+		DeleteOptions: o,
+	}
+	meta := pod.GetMetadata()
+	e.Metadata.Name = meta.GetName()
+	e.Metadata.Namespace = meta.GetNamespace()
+	return c.do(ctx, "POST", url, e, nil)
+}
+
 func (c *Client) Update(ctx context.Context, req Resource, options ...Option) error {
 	url, err := resourceURL(c.Endpoint, req, true, options...)
 	if err != nil {
 		return err
 	}
-	return c.do(ctx, "PUT", url, req, req)
+	return c.do(ctx, "PUT", url, req, req, options...)
 }
 
-func (c *Client) Get(ctx context.Context, namespace, name string, resp Resource, options ...Option) error {
-	url, err := resourceGetURL(c.Endpoint, namespace, name, resp, options...)
+// GetScale reads the "scale" subresource of a resource, such as a Deployment,
+// ReplicaSet, StatefulSet or a custom resource with a scale subresource. The
+// result is unmarshaled into scale, not r.
+//
+// The type of scale must match the API version of r. For example, "apps/v1"
+// and "autoscaling/v1" resources use "autoscaling/v1" Scale objects.
+//
+//		deployment := new(appsv1.Deployment)
+//		if err := client.Get(ctx, "my-namespace", "my-deployment", deployment); err != nil {
+//			// handle error
+//		}
+//		var scale autoscalingv1.Scale
+//		if err := client.GetScale(ctx, deployment, &scale); err != nil {
+//			// handle error
+//		}
+//		fmt.Println(scale.Status.GetReplicas())
+//
+func (c *Client) GetScale(ctx context.Context, r Resource, scale Resource, options ...Option) error {
+	url, err := resourceURL(c.Endpoint, r, true, append(readOptions(options), Subresource("scale"))...)
 	if err != nil {
 		return err
 	}
-	return c.do(ctx, "GET", url, nil, resp)
+	return c.do(ctx, "GET", url, nil, scale, options...)
 }
 
-func (c *Client) List(ctx context.Context, namespace string, resp ResourceList, options ...Option) error {
-	url, err := resourceListURL(c.Endpoint, namespace, resp, options...)
+type scale struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas int32 `json:"replicas"`
+	} `json:"spec"`
+}
+
+// scaleAPIVersion returns the API version of the Scale object used by the
+// scale subresource of a resource type. Older API groups defined their own
+// Scale kinds, everything else uses "autoscaling/v1".
+func scaleAPIVersion(t resourceType) string {
+	switch {
+	case t.apiGroup == "extensions" && t.apiVersion == "v1beta1",
+		t.apiGroup == "apps" && t.apiVersion == "v1beta1",
+		t.apiGroup == "apps" && t.apiVersion == "v1beta2":
+		return t.apiGroup + "/" + t.apiVersion
+	}
+	return "autoscaling/v1"
+}
+
+// UpdateScale sets the desired number of replicas of a resource through its
+// "scale" subresource. Unlike Update, this doesn't require permissions on the
+// resource itself, and doesn't conflict with concurrent changes to the rest of
+// the resource's spec.
+func (c *Client) UpdateScale(ctx context.Context, r Resource, replicas int32, options ...Option) error {
+	url, err := resourceURL(c.Endpoint, r, true, append(options[:len(options):len(options)], Subresource("scale"))...)
 	if err != nil {
 		return err
 	}
-	return c.do(ctx, "GET", url, nil, resp)
+	s := &scale{Kind: "Scale", APIVersion: scaleAPIVersion(resources[reflect.TypeOf(r)])}
+	meta := r.GetMetadata()
+	s.Metadata.Name = meta.GetName()
+	s.Metadata.Namespace = meta.GetNamespace()
+	s.Spec.Replicas = replicas
+	return c.do(ctx, "PUT", url, s, nil)
 }
 
-func (c *Client) do(ctx context.Context, verb, url string, req, resp interface{}) error {
-	var (
-		contentType string
-		body        io.Reader
-	)
-	if req != nil {
-		ct, data, err := marshal(req)
-		if err != nil {
-			return fmt.Errorf("encoding object: %v", err)
-		}
-		contentType = ct
-		body = bytes.NewReader(data)
+// PatchType is the encoding of a patch document. See the Kubernetes docs for
+// details on each format.
+//
+// See https://kubernetes.io/docs/tasks/run-application/update-api-object-kubectl-patch/
+type PatchType string
+
+const (
+	// PatchJSON is a JSON Patch document, as defined by RFC 6902.
+	PatchJSON PatchType = "application/json-patch+json"
+	// PatchMerge is a JSON Merge Patch document, as defined by RFC 7386.
+	PatchMerge PatchType = "application/merge-patch+json"
+	// PatchStrategicMerge is a Kubernetes strategic merge patch. Strategic merge
+	// patches aren't supported by custom resources.
+	PatchStrategicMerge PatchType = "application/strategic-merge-patch+json"
+)
+
+// Patch applies a patch document to a resource. The resource must have a name,
+// and a namespace if it's namespaced. The result is unmarshaled into r.
+//
+//		patch := []byte(`{"metadata":{"labels":{"hello":"world"}}}`)
+//		if err := client.Patch(ctx, configMap, k8s.PatchMerge, patch); err != nil {
+//			// handle error
+//		}
+//
+func (c *Client) Patch(ctx context.Context, r Resource, pt PatchType, data []byte, options ...Option) error {
+	url, err := resourceURL(c.Endpoint, r, true, options...)
+	if err != nil {
+		return err
 	}
-	r, err := http.NewRequest(verb, url, body)
+	return c.do(ctx, "PATCH", url, &patch{pt, data}, r, options...)
+}
+
+func (c *Client) Get(ctx context.Context, namespace, name string, resp Resource, options ...Option) error {
+	url, err := resourceGetURL(c.Endpoint, namespace, name, resp, readOptions(options)...)
 	if err != nil {
-		return fmt.Errorf("new request: %v", err)
+		return err
 	}
-	if contentType != "" {
-		r.Header.Set("Content-Type", contentType)
-		r.Header.Set("Accept", contentType)
-	} else if resp != nil {
-		r.Header.Set("Accept", contentTypeFor(resp))
+	return c.do(ctx, "GET", url, nil, resp, options...)
+}
+
+func (c *Client) List(ctx context.Context, namespace string, resp ResourceList, options ...Option) error {
+	url, err := resourceListURL(c.Endpoint, namespace, resp, readOptions(options)...)
+	if err != nil {
+		return err
 	}
-	if c.SetHeaders != nil {
-		c.SetHeaders(r.Header)
+	return c.do(ctx, "GET", url, nil, resp, options...)
+}
+
+// do performs a request, encoding req as the body and decoding the response
+// into resp. Either may be nil. If the API server doesn't support protobuf for
+// the request, it's retried using JSON.
+func (c *Client) do(ctx context.Context, verb, url string, req, resp interface{}, options ...Option) error {
+	contentType := contentTypeJSON
+	if (req != nil && c.contentTypeFor(req, options) == contentTypePB) ||
+		(resp != nil && c.contentTypeFor(resp, options) == contentTypePB) {
+		contentType = contentTypePB
+	}
+	err := c.doContentType(ctx, verb, url, req, resp, contentType)
+	if contentType == contentTypePB && unsupportedContentType(err) {
+		return c.doContentType(ctx, verb, url, req, resp, contentTypeJSON)
 	}
+	return err
+}
 
-	re, err := c.client().Do(r)
+func (c *Client) doContentType(ctx context.Context, verb, url string, req, resp interface{}, preferred string) error {
+	r, err := c.newResourceRequest(ctx, verb, url, req, resp, preferred)
+	if err != nil {
+		return err
+	}
+	re, err := c.send(r)
 	if err != nil {
 		return fmt.Errorf("performing request: %v", err)
 	}
@@ -489,6 +873,47 @@ func (c *Client) do(ctx context.Context, verb, url string, req, resp interface{}
 		if err := unmarshal(respBody, respCT, resp); err != nil {
 			return fmt.Errorf("decode response: %v", err)
 		}
+		setTypeMeta(resp)
 	}
 	return nil
 }
+
+// newResourceRequest creates a request, encoding req as the body and asking
+// for a response suitable for decoding into resp.
+func (c *Client) newResourceRequest(ctx context.Context, verb, url string, req, resp interface{}, preferred string) (*http.Request, error) {
+	var (
+		contentType string
+		body        io.Reader
+	)
+	if req != nil {
+		setTypeMeta(req)
+		ct, data, err := marshal(req, preferred)
+		if err != nil {
+			return nil, fmt.Errorf("encoding object: %v", err)
+		}
+		contentType = ct
+		body = bytes.NewReader(data)
+	}
+	r, err := http.NewRequest(verb, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %v", err)
+	}
+	r = r.WithContext(ctx)
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	switch {
+	case resp != nil && preferred == contentTypePB:
+		r.Header.Set("Accept", contentTypeFor(resp))
+	case resp != nil:
+		r.Header.Set("Accept", contentTypeJSON)
+	case contentType == contentTypePB:
+		r.Header.Set("Accept", contentTypePB)
+	case contentType != "":
+		r.Header.Set("Accept", contentTypeJSON)
+	}
+	if c.SetHeaders != nil {
+		c.SetHeaders(r.Header)
+	}
+	return r, nil
+}
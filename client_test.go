@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/ericchiang/k8s"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+	autoscalingv1 "github.com/ericchiang/k8s/apis/autoscaling/v1"
 	corev1 "github.com/ericchiang/k8s/apis/core/v1"
 	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
 )
@@ -439,3 +441,104 @@ func TestLabelSelector(t *testing.T) {
 		}
 	})
 }
+
+func TestScaleDeployment(t *testing.T) {
+	withNamespace(t, func(client *k8s.Client, namespace string) {
+		labels := map[string]string{"app": "my-app"}
+		deployment := &appsv1.Deployment{
+			Metadata: &metav1.ObjectMeta{
+				Name:      k8s.String("my-deployment"),
+				Namespace: &namespace,
+			},
+			Spec: &appsv1.DeploymentSpec{
+				Replicas: k8s.Int32(1),
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: &corev1.PodTemplateSpec{
+					Metadata: &metav1.ObjectMeta{Labels: labels},
+					Spec: &corev1.PodSpec{
+						Containers: []*corev1.Container{
+							{Name: k8s.String("nginx"), Image: k8s.String("nginx")},
+						},
+					},
+				},
+			},
+		}
+		if err := client.Create(context.TODO(), deployment); err != nil {
+			t.Fatalf("create deployment: %v", err)
+		}
+
+		if err := client.UpdateScale(context.TODO(), deployment, 3); err != nil {
+			t.Fatalf("update scale: %v", err)
+		}
+
+		var scale autoscalingv1.Scale
+		if err := client.GetScale(context.TODO(), deployment, &scale); err != nil {
+			t.Fatalf("get scale: %v", err)
+		}
+		if got := scale.GetSpec().GetReplicas(); got != 3 {
+			t.Errorf("expected 3 replicas, got %d", got)
+		}
+	})
+}
+
+func TestUpdateStatus(t *testing.T) {
+	withNamespace(t, func(client *k8s.Client, namespace string) {
+		var ns corev1.Namespace
+		if err := client.Get(context.TODO(), "", namespace, &ns); err != nil {
+			t.Fatalf("get namespace: %v", err)
+		}
+		if err := client.UpdateStatus(context.TODO(), &ns); err != nil {
+			t.Errorf("update namespace status: %v", err)
+		}
+		patch := []byte(`{"status":{"phase":"Active"}}`)
+		if err := client.PatchStatus(context.TODO(), &ns, k8s.PatchMerge, patch); err != nil {
+			t.Errorf("patch namespace status: %v", err)
+		}
+
+		cm := &corev1.ConfigMap{
+			Metadata: &metav1.ObjectMeta{
+				Name:      k8s.String("my-configmap"),
+				Namespace: &namespace,
+			},
+		}
+		if err := client.Create(context.TODO(), cm); err != nil {
+			t.Fatalf("create configmap: %v", err)
+		}
+		if err := client.UpdateStatus(context.TODO(), cm); err == nil {
+			t.Errorf("expected error updating status of a configmap")
+		}
+	})
+}
+
+func TestPatch(t *testing.T) {
+	withNamespace(t, func(client *k8s.Client, namespace string) {
+		cm := &corev1.ConfigMap{
+			Metadata: &metav1.ObjectMeta{
+				Name:      k8s.String("my-configmap"),
+				Namespace: &namespace,
+			},
+			Data: map[string]string{"foo": "bar"},
+		}
+		if err := client.Create(context.TODO(), cm); err != nil {
+			t.Fatalf("create configmap: %v", err)
+		}
+
+		tests := []struct {
+			patchType k8s.PatchType
+			patch     string
+			want      map[string]string
+		}{
+			{k8s.PatchMerge, `{"data":{"spam":"eggs"}}`, map[string]string{"foo": "bar", "spam": "eggs"}},
+			{k8s.PatchStrategicMerge, `{"data":{"foo":null}}`, map[string]string{"spam": "eggs"}},
+			{k8s.PatchJSON, `[{"op":"replace","path":"/data/spam","value":"ham"}]`, map[string]string{"spam": "ham"}},
+		}
+		for _, test := range tests {
+			if err := client.Patch(context.TODO(), cm, test.patchType, []byte(test.patch)); err != nil {
+				t.Fatalf("patch configmap %s: %v", test.patchType, err)
+			}
+			if !reflect.DeepEqual(cm.Data, test.want) {
+				t.Errorf("patch %s: wanted data=%v, got=%v", test.patchType, test.want, cm.Data)
+			}
+		}
+	})
+}
@@ -5,7 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
 
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
 	"github.com/ericchiang/k8s/runtime"
 	"github.com/golang/protobuf/proto"
 )
@@ -15,6 +21,13 @@ const (
 	contentTypeJSON = "application/json"
 )
 
+// Content types which can be used to talk to the API server. See the client's
+// ContentType field.
+const (
+	ContentTypeProtobuf = contentTypePB
+	ContentTypeJSON     = contentTypeJSON
+)
+
 func contentTypeFor(i interface{}) string {
 	if _, ok := i.(proto.Message); ok {
 		return contentTypePB
@@ -22,12 +35,55 @@ func contentTypeFor(i interface{}) string {
 	return contentTypeJSON
 }
 
+// contentTypeFor returns the content type used to encode and decode i. Generated
+// types use protobuf unless JSON has been requested by the client or an option.
+func (c *Client) contentTypeFor(i interface{}, options []Option) string {
+	preferred := c.ContentType
+	for _, option := range options {
+		if ct, ok := option.(contentTypeOption); ok {
+			preferred = string(ct)
+		}
+	}
+	if preferred == contentTypeJSON {
+		return contentTypeJSON
+	}
+	return contentTypeFor(i)
+}
+
+// contentTypeOption overrides the client's content type for a single request.
+type contentTypeOption string
+
+func (o contentTypeOption) updateDelete(r Resource, d *deleteOptions)  {}
+func (o contentTypeOption) updateURL(base string, v url.Values) string { return base }
+
+// ContentType overrides the client's content type for a single request. Use
+// ContentTypeJSON to send and receive JSON, for example when debugging.
+//
+//	if err := client.Get(ctx, "default", "my-configmap", cm, k8s.ContentType(k8s.ContentTypeJSON)); err != nil {
+//		// handle error
+//	}
+func ContentType(contentType string) Option {
+	return contentTypeOption(contentType)
+}
+
+// unsupportedContentType reports if the API server rejected a request because
+// of its content type, or the content type it asked for. Aggregated API servers
+// may not support protobuf.
+func unsupportedContentType(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && (apiErr.Code == http.StatusNotAcceptable || apiErr.Code == http.StatusUnsupportedMediaType)
+}
+
 // marshal encodes an object and returns the content type of that resource
 // and the marshaled representation.
 //
-// marshal prefers protobuf encoding, but falls back to JSON.
-func marshal(i interface{}) (string, []byte, error) {
-	if _, ok := i.(proto.Message); ok {
+// marshal uses protobuf encoding if requested and the object supports it, and
+// JSON otherwise.
+func marshal(i interface{}, contentType string) (string, []byte, error) {
+	if p, ok := i.(*patch); ok {
+		return string(p.patchType), p.data, nil
+	}
+	if _, ok := i.(proto.Message); ok && contentType == contentTypePB {
 		data, err := marshalPB(i)
 		return contentTypePB, data, err
 	}
@@ -35,6 +91,12 @@ func marshal(i interface{}) (string, []byte, error) {
 	return contentTypeJSON, data, err
 }
 
+// patch is a pre-encoded patch document, sent as is.
+type patch struct {
+	patchType PatchType
+	data      []byte
+}
+
 // unmarshal decoded an object given the content type of the encoded form.
 func unmarshal(data []byte, contentType string, i interface{}) error {
 	msg, isPBMsg := i.(proto.Message)
@@ -44,12 +106,8 @@ func unmarshal(data []byte, contentType string, i interface{}) error {
 		}
 		return nil
 	}
-	if isPBMsg {
-		// only decode into JSON of a protobuf message if the type
-		// explicitly implements json.Unmarshaler
-		if _, ok := i.(json.Unmarshaler); !ok {
-			return fmt.Errorf("cannot decode json payload into protobuf object %T", i)
-		}
+	if isPBMsg && !decodesJSON(i) {
+		return fmt.Errorf("cannot decode json payload into protobuf object %T", i)
 	}
 	if err := json.Unmarshal(data, i); err != nil {
 		return fmt.Errorf("decode json: %v", err)
@@ -57,6 +115,26 @@ func unmarshal(data []byte, contentType string, i interface{}) error {
 	return nil
 }
 
+// apisPackage is the import path prefix of the generated API packages, such as
+// "github.com/ericchiang/k8s/apis/core/v1".
+var apisPackage = path.Dir(path.Dir(reflect.TypeOf(metav1.Status{}).PkgPath())) + "/"
+
+// decodesJSON reports if a protobuf message can be decoded from the JSON sent
+// by the API server. The generated API types can, since the fields whose JSON
+// form differs from their protobuf form, such as IntOrString, Quantity and
+// inlined structs, implement their own JSON encoding. Other messages must
+// explicitly implement json.Unmarshaler.
+func decodesJSON(i interface{}) bool {
+	if _, ok := i.(json.Unmarshaler); ok {
+		return true
+	}
+	t := reflect.TypeOf(i)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.HasPrefix(t.PkgPath(), apisPackage)
+}
+
 var magicBytes = []byte{0x6b, 0x38, 0x73, 0x00}
 
 func unmarshalPB(b []byte, msg proto.Message) error {
@@ -0,0 +1,312 @@
+package k8s_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+// headerRecorder records the content type headers of requests.
+type headerRecorder struct {
+	mu      sync.Mutex
+	headers []string
+	next    http.RoundTripper
+}
+
+func (h *headerRecorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	h.headers = append(h.headers, r.Method+" "+r.Header.Get("Content-Type")+" "+r.Header.Get("Accept"))
+	h.mu.Unlock()
+	return h.next.RoundTrip(r)
+}
+
+func recordHeaders(client *k8s.Client) *headerRecorder {
+	h := &headerRecorder{next: http.DefaultTransport}
+	if client.Client != nil && client.Client.Transport != nil {
+		h.next = client.Client.Transport
+	}
+	client.Client = &http.Client{Transport: h}
+	return h
+}
+
+func TestContentType(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	// Secrets, since the tests in this package register ConfigMaps as a
+	// JSON only type.
+	secret := &corev1.Secret{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-secret"),
+			Namespace: k8s.String("default"),
+		},
+		StringData: map[string]string{"hello": "world"},
+	}
+
+	pb := "application/vnd.kubernetes.protobuf"
+	tests := []struct {
+		name    string
+		setup   func(c *k8s.Client)
+		options []k8s.Option
+		want    []string
+	}{
+		{
+			name: "default",
+			want: []string{"POST " + pb + " " + pb, "GET  " + pb, "DELETE application/json application/json"},
+		},
+		{
+			name:  "client",
+			setup: func(c *k8s.Client) { c.ContentType = k8s.ContentTypeJSON },
+			want:  []string{"POST application/json application/json", "GET  application/json", "DELETE application/json application/json"},
+		},
+		{
+			name:    "option",
+			options: []k8s.Option{k8s.ContentType(k8s.ContentTypeJSON)},
+			want:    []string{"POST application/json application/json", "GET  application/json", "DELETE application/json application/json"},
+		},
+	}
+	for _, test := range tests {
+		client := srv.Client()
+		if test.setup != nil {
+			test.setup(client)
+		}
+		h := recordHeaders(client)
+
+		created := *secret
+		if err := client.Create(ctx, &created, test.options...); err != nil {
+			t.Fatalf("%s: create: %v", test.name, err)
+		}
+		got := new(corev1.Secret)
+		if err := client.Get(ctx, "default", "my-secret", got, test.options...); err != nil {
+			t.Fatalf("%s: get: %v", test.name, err)
+		}
+		if got.Metadata.GetUid() != created.Metadata.GetUid() {
+			t.Errorf("%s: unexpected secret %v", test.name, got.Metadata)
+		}
+		if err := client.Delete(ctx, got, test.options...); err != nil {
+			t.Fatalf("%s: delete: %v", test.name, err)
+		}
+
+		if len(h.headers) != len(test.want) {
+			t.Errorf("%s: expected %d requests, got %q", test.name, len(test.want), h.headers)
+			continue
+		}
+		for i, want := range test.want {
+			if h.headers[i] != want {
+				t.Errorf("%s: request %d: got %q, want %q", test.name, i, h.headers[i], want)
+			}
+		}
+	}
+}
+
+// An API server which doesn't support protobuf rejects protobuf requests, and
+// requests asking for protobuf responses.
+func TestContentTypeFallback(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.Header.Get("Accept"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		reject := func(code int) {
+			w.WriteHeader(code)
+			json.NewEncoder(w).Encode(&metav1.Status{
+				Status: k8s.String("Failure"),
+				Code:   k8s.Int32(int32(code)),
+			})
+		}
+		switch {
+		case r.Header.Get("Content-Type") == "application/vnd.kubernetes.protobuf":
+			reject(http.StatusUnsupportedMediaType)
+			return
+		case r.Header.Get("Accept") != "application/json":
+			reject(http.StatusNotAcceptable)
+			return
+		}
+		var secret corev1.Secret
+		if r.Method == "POST" {
+			json.NewDecoder(r.Body).Decode(&secret)
+		} else {
+			secret.Metadata = &metav1.ObjectMeta{Name: k8s.String("my-secret")}
+		}
+		secret.Metadata.ResourceVersion = k8s.String("1")
+		json.NewEncoder(w).Encode(&secret)
+	}))
+	defer s.Close()
+
+	client := &k8s.Client{Endpoint: s.URL, Client: s.Client()}
+	ctx := context.Background()
+
+	secret := &corev1.Secret{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-secret"),
+			Namespace: k8s.String("default"),
+		},
+	}
+	if err := client.Create(ctx, secret); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if secret.Metadata.GetResourceVersion() != "1" {
+		t.Errorf("create response wasn't decoded")
+	}
+	got := new(corev1.Secret)
+	if err := client.Get(ctx, "default", "my-secret", got); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Metadata.GetName() != "my-secret" {
+		t.Errorf("get response wasn't decoded")
+	}
+
+	want := []string{
+		"POST application/vnd.kubernetes.protobuf",
+		"POST application/json",
+		"GET application/vnd.kubernetes.protobuf",
+		"GET application/json",
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("expected requests %q, got %q", want, requests)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Errorf("request %d: got %q, want %q", i, requests[i], want[i])
+		}
+	}
+}
+
+// Responses of an API server, as JSON, using fields whose JSON form differs
+// from their protobuf form.
+var jsonResponses = map[string]string{
+	"/api/v1/namespaces/default/pods/my-pod": `{
+		"kind": "Pod",
+		"apiVersion": "v1",
+		"metadata": {"name": "my-pod", "namespace": "default", "creationTimestamp": "2018-01-02T03:04:05Z"},
+		"spec": {
+			"containers": [{
+				"name": "web",
+				"image": "nginx",
+				"ports": [{"name": "http", "containerPort": 8080}],
+				"resources": {"limits": {"cpu": "500m", "memory": "128Mi"}},
+				"livenessProbe": {"httpGet": {"path": "/healthz", "port": "http"}, "periodSeconds": 10},
+				"readinessProbe": {"tcpSocket": {"port": 8080}}
+			}]
+		}
+	}`,
+	"/apis/apps/v1/namespaces/default/deployments/my-deployment": `{
+		"kind": "Deployment",
+		"apiVersion": "apps/v1",
+		"metadata": {"name": "my-deployment", "namespace": "default"},
+		"spec": {
+			"replicas": 3,
+			"selector": {"matchLabels": {"app": "web"}},
+			"strategy": {"type": "RollingUpdate", "rollingUpdate": {"maxSurge": "25%", "maxUnavailable": 1}},
+			"template": {
+				"metadata": {"labels": {"app": "web"}},
+				"spec": {"containers": [{"name": "web", "image": "nginx"}]}
+			}
+		}
+	}`,
+	"/apis/example.com/v1/namespaces/default/protoonlies/my-object": `{
+		"metadata": {"name": "my-object", "namespace": "default"}
+	}`,
+}
+
+// protoOnly is a protobuf message without a JSON decoder, which can't be decoded
+// from JSON.
+type protoOnly struct {
+	Metadata *metav1.ObjectMeta `protobuf:"bytes,1,opt,name=metadata" json:"metadata,omitempty"`
+}
+
+func (p *protoOnly) GetMetadata() *metav1.ObjectMeta { return p.Metadata }
+func (p *protoOnly) Reset()                          { *p = protoOnly{} }
+func (p *protoOnly) String() string                  { return "" }
+func (p *protoOnly) ProtoMessage()                   {}
+
+func init() {
+	k8s.Register("example.com", "v1", "protoonlies", true, &protoOnly{})
+}
+
+func TestDecodeJSON(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := jsonResponses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer s.Close()
+	client := &k8s.Client{Endpoint: s.URL, Client: s.Client(), ContentType: k8s.ContentTypeJSON}
+	ctx := context.Background()
+
+	var pod corev1.Pod
+	if err := client.Get(ctx, "default", "my-pod", &pod); err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	if ts := pod.GetMetadata().GetCreationTimestamp().GetSeconds(); ts != 1514862245 {
+		t.Errorf("expected creation timestamp to be decoded, got %d", ts)
+	}
+	containers := pod.GetSpec().GetContainers()
+	if len(containers) != 1 {
+		t.Fatalf("expected 1 container, got %d", len(containers))
+	}
+	c := containers[0]
+	if got := c.GetPorts()[0].GetContainerPort(); got != 8080 {
+		t.Errorf("expected container port 8080, got %d", got)
+	}
+	if got := c.GetResources().GetLimits()["cpu"].GetString_(); got != "500m" {
+		t.Errorf("expected cpu limit 500m, got %q", got)
+	}
+	if got := c.GetResources().GetLimits()["memory"].GetString_(); got != "128Mi" {
+		t.Errorf("expected memory limit 128Mi, got %q", got)
+	}
+	httpGet := c.GetLivenessProbe().GetHandler().GetHttpGet()
+	if httpGet.GetPath() != "/healthz" || httpGet.GetPort().GetStrVal() != "http" {
+		t.Errorf("expected liveness probe of http port /healthz, got %v", httpGet)
+	}
+	if got := c.GetLivenessProbe().GetPeriodSeconds(); got != 10 {
+		t.Errorf("expected liveness probe period of 10s, got %d", got)
+	}
+	if got := c.GetReadinessProbe().GetHandler().GetTcpSocket().GetPort().GetIntVal(); got != 8080 {
+		t.Errorf("expected readiness probe of port 8080, got %d", got)
+	}
+
+	var deployment appsv1.Deployment
+	if err := client.Get(ctx, "default", "my-deployment", &deployment); err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+	spec := deployment.GetSpec()
+	if spec.GetReplicas() != 3 || spec.GetSelector().GetMatchLabels()["app"] != "web" {
+		t.Errorf("expected deployment spec to be decoded, got %v", spec)
+	}
+	rollingUpdate := spec.GetStrategy().GetRollingUpdate()
+	if got := rollingUpdate.GetMaxSurge().GetStrVal(); got != "25%" {
+		t.Errorf("expected max surge of 25%%, got %q", got)
+	}
+	if got := rollingUpdate.GetMaxUnavailable().GetIntVal(); got != 1 {
+		t.Errorf("expected max unavailable of 1, got %d", got)
+	}
+	if got := spec.GetTemplate().GetSpec().GetContainers()[0].GetImage(); got != "nginx" {
+		t.Errorf("expected template to be decoded, got image %q", got)
+	}
+
+	err := client.Get(ctx, "default", "my-object", new(protoOnly))
+	if err == nil || !strings.Contains(err.Error(), "cannot decode json payload") {
+		t.Errorf("expected decoding JSON into a protobuf message without a JSON decoder to fail, got %v", err)
+	}
+}
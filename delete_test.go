@@ -0,0 +1,191 @@
+package k8s_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+func newSecret(name string, finalizers ...string) *corev1.Secret {
+	return &corev1.Secret{
+		Metadata: &metav1.ObjectMeta{
+			Name:       k8s.String(name),
+			Namespace:  k8s.String("default"),
+			Finalizers: finalizers,
+		},
+	}
+}
+
+func TestDeleteAndWait(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	secret := newSecret("my-secret", "example.com/cleanup")
+	if err := client.Create(ctx, secret); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Remove the finalizer once the secret is marked for deletion, like a
+	// controller would.
+	finalized := make(chan error, 1)
+	go func() {
+		for {
+			s := new(corev1.Secret)
+			if err := client.Get(ctx, "default", "my-secret", s); err != nil {
+				finalized <- err
+				return
+			}
+			if s.Metadata.DeletionTimestamp == nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			s.Metadata.Finalizers = nil
+			finalized <- client.Update(ctx, s)
+			return
+		}
+	}()
+
+	if err := client.DeleteAndWait(ctx, secret, 10*time.Second); err != nil {
+		t.Fatalf("delete and wait: %v", err)
+	}
+	if err := <-finalized; err != nil {
+		t.Fatalf("remove finalizer: %v", err)
+	}
+	err := client.Get(ctx, "default", "my-secret", new(corev1.Secret))
+	if apiErr, ok := err.(*k8s.APIError); !ok || apiErr.Code != http.StatusNotFound {
+		t.Errorf("expected secret to be deleted, got %v", err)
+	}
+
+	// Already deleted.
+	if err := client.DeleteAndWait(ctx, secret, 10*time.Second); err != nil {
+		t.Errorf("delete and wait for deleted secret: %v", err)
+	}
+
+	// Finalizers which are never removed.
+	stuck := newSecret("stuck", "example.com/cleanup")
+	if err := client.Create(ctx, stuck); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := client.DeleteAndWait(ctx, stuck, 100*time.Millisecond); err == nil {
+		t.Errorf("expected delete and wait to time out")
+	}
+}
+
+func TestDeletePreconditions(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	secret := newSecret("my-secret")
+	if err := client.Create(ctx, secret); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	rv := secret.Metadata.GetResourceVersion()
+	secret.StringData = map[string]string{"hello": "world"}
+	if err := client.Update(ctx, secret); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	tests := []struct {
+		option k8s.Option
+		code   int
+	}{
+		{k8s.DeletePreconditionUID("other-uid"), http.StatusConflict},
+		{k8s.DeletePreconditionResourceVersion(rv), http.StatusConflict},
+		{k8s.DeletePreconditionResourceVersion(secret.Metadata.GetResourceVersion()), 0},
+	}
+	for i, test := range tests {
+		err := client.Delete(ctx, secret, test.option)
+		if test.code == 0 {
+			if err != nil {
+				t.Errorf("case %d: delete: %v", i, err)
+			}
+			continue
+		}
+		if apiErr, ok := err.(*k8s.APIError); !ok || apiErr.Code != test.code {
+			t.Errorf("case %d: expected status code %d, got %v", i, test.code, err)
+		}
+	}
+}
+
+func TestDeleteAndWaitWatchErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		// Body of watch responses.
+		watch string
+		// Timeout of DeleteAndWait.
+		timeout time.Duration
+		code    int
+	}{
+		{
+			name:    "forbidden",
+			watch:   `{"type":"ERROR","object":{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403}}` + "\n",
+			timeout: 5 * time.Second,
+			code:    http.StatusForbidden,
+		},
+		{
+			name:    "gone",
+			watch:   `{"type":"ERROR","object":{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Expired","code":410}}` + "\n",
+			timeout: 500 * time.Millisecond,
+		},
+		{
+			name:    "closed",
+			timeout: 500 * time.Millisecond,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				watches int
+			)
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Query().Get("watch") == "true" {
+					mu.Lock()
+					watches++
+					mu.Unlock()
+					w.Write([]byte(test.watch))
+					return
+				}
+				w.Write([]byte(`{"metadata":{"name":"my-secret","namespace":"default","uid":"my-uid"}}`))
+			}))
+			defer s.Close()
+
+			client := &k8s.Client{Endpoint: s.URL, Client: s.Client(), ContentType: k8s.ContentTypeJSON}
+			secret := newSecret("my-secret")
+			secret.Metadata.Uid = k8s.String("my-uid")
+			err := client.DeleteAndWait(context.Background(), secret, test.timeout)
+			if err == nil {
+				t.Fatalf("expected delete and wait to fail")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if test.code != 0 {
+				if apiErr, ok := err.(*k8s.APIError); !ok || apiErr.Code != test.code {
+					t.Errorf("expected status code %d, got %v", test.code, err)
+				}
+				if watches != 1 {
+					t.Errorf("expected watch not to be retried, got %d watches", watches)
+				}
+				return
+			}
+			// Retries back off, starting at 100ms.
+			if watches < 2 || watches > 5 {
+				t.Errorf("expected watch to be retried with a backoff, got %d watches", watches)
+			}
+		})
+	}
+}
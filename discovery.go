@@ -2,7 +2,12 @@ package k8s
 
 import (
 	"context"
+	"fmt"
 	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
 )
@@ -23,13 +28,49 @@ type Version struct {
 // resources of the server.
 type Discovery struct {
 	client *Client
+	cache  *discoveryCache
+
+	// refreshed records when the cached resources of each group version were
+	// last refreshed because they were missing a resource.
+	mu        sync.Mutex
+	refreshed map[string]time.Time
 }
 
 func NewDiscoveryClient(c *Client) *Discovery {
-	return &Discovery{c}
+	return &Discovery{client: c}
+}
+
+// NewCachedDiscoveryClient returns a discovery client which caches responses
+// from the API server, similar to kubectl's "~/.kube/cache/discovery" directory.
+//
+// Cached responses are used for up to ttl before being revalidated with the
+// API server using the response's ETag. If dir is non-empty, responses are
+// also persisted to disk so they can be shared between processes.
+//
+//		home, err := os.UserHomeDir()
+//		if err != nil {
+//			// handle error
+//		}
+//		dir := filepath.Join(home, ".cache", "my-tool", "discovery")
+//		discovery := k8s.NewCachedDiscoveryClient(client, dir, 10*time.Minute)
+//
+func NewCachedDiscoveryClient(c *Client, dir string, ttl time.Duration) *Discovery {
+	return &Discovery{client: c, cache: newDiscoveryCache(c.Endpoint, dir, ttl)}
+}
+
+// Invalidate clears any cached discovery information, forcing subsequent calls
+// to query the API server. It's a no-op for clients without a cache.
+func (d *Discovery) Invalidate() error {
+	if d.cache == nil {
+		return nil
+	}
+	return d.cache.invalidate()
 }
 
 func (d *Discovery) get(ctx context.Context, path string, resp interface{}) error {
+	if d.cache != nil {
+		return d.cache.get(ctx, d.client, path, resp)
+	}
 	return d.client.do(ctx, "GET", urlForPath(d.client.Endpoint, path), nil, resp)
 }
 
@@ -41,6 +82,17 @@ func (d *Discovery) Version(ctx context.Context) (*Version, error) {
 	return &v, nil
 }
 
+// APIVersions returns the versions of the legacy core API group, served at "/api".
+func (d *Discovery) APIVersions(ctx context.Context) (*metav1.APIVersions, error) {
+	var versions metav1.APIVersions
+	if err := d.get(ctx, "api", &versions); err != nil {
+		return nil, err
+	}
+	return &versions, nil
+}
+
+// APIGroups returns the API groups served under "/apis". The legacy core group
+// isn't included, use APIVersions or ServerGroups instead.
 func (d *Discovery) APIGroups(ctx context.Context) (*metav1.APIGroupList, error) {
 	var groups metav1.APIGroupList
 	if err := d.get(ctx, "apis", &groups); err != nil {
@@ -49,7 +101,42 @@ func (d *Discovery) APIGroups(ctx context.Context) (*metav1.APIGroupList, error)
 	return &groups, nil
 }
 
+// ServerGroups returns all API groups served by the API server, including the
+// legacy core group, which is always first and has an empty name.
+func (d *Discovery) ServerGroups(ctx context.Context) (*metav1.APIGroupList, error) {
+	core, err := d.APIGroup(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := d.APIGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups.Groups = append([]*metav1.APIGroup{core}, groups.Groups...)
+	return groups, nil
+}
+
+// APIGroup returns an API group by name. The empty name refers to the legacy
+// core group.
 func (d *Discovery) APIGroup(ctx context.Context, name string) (*metav1.APIGroup, error) {
+	if name == "" {
+		versions, err := d.APIVersions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		group := &metav1.APIGroup{Name: String("")}
+		for _, v := range versions.Versions {
+			group.Versions = append(group.Versions, &metav1.GroupVersionForDiscovery{
+				GroupVersion: String(v),
+				Version:      String(v),
+			})
+		}
+		if len(group.Versions) > 0 {
+			group.PreferredVersion = group.Versions[0]
+		}
+		return group, nil
+	}
+
 	var group metav1.APIGroup
 	if err := d.get(ctx, path.Join("apis", name), &group); err != nil {
 		return nil, err
@@ -57,10 +144,130 @@ func (d *Discovery) APIGroup(ctx context.Context, name string) (*metav1.APIGroup
 	return &group, nil
 }
 
+// APIResources returns the resources served by a group version. The empty
+// group name refers to the legacy core group.
 func (d *Discovery) APIResources(ctx context.Context, groupName, groupVersion string) (*metav1.APIResourceList, error) {
 	var list metav1.APIResourceList
-	if err := d.get(ctx, path.Join("apis", groupName, groupVersion), &list); err != nil {
+	if err := d.get(ctx, apiResourcesPath(groupName, groupVersion), &list); err != nil {
 		return nil, err
 	}
 	return &list, nil
 }
+
+func apiResourcesPath(groupName, groupVersion string) string {
+	if groupName == "" {
+		return path.Join("api", groupVersion)
+	}
+	return path.Join("apis", groupName, groupVersion)
+}
+
+// refreshAPIResources marks the cached resources of a group version as stale,
+// so the next call to APIResources revalidates them with the API server. This
+// is done at most once per cache TTL for each group version, so repeatedly
+// looking up a missing resource doesn't query the API server each time. It
+// reports whether the resources were marked stale.
+func (d *Discovery) refreshAPIResources(groupName, groupVersion string) bool {
+	if d.cache == nil {
+		return false
+	}
+	p := apiResourcesPath(groupName, groupVersion)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last, ok := d.refreshed[p]; ok && time.Since(last) < d.cache.ttl {
+		return false
+	}
+	if d.refreshed == nil {
+		d.refreshed = map[string]time.Time{}
+	}
+	d.refreshed[p] = time.Now()
+	d.cache.expire(p)
+	return true
+}
+
+// GroupDiscoveryError is returned by ServerResources when the resources of some
+// group versions couldn't be determined. This commonly happens when an
+// aggregated API server is unavailable.
+type GroupDiscoveryError struct {
+	// Errors for each group version that failed, keyed by group version, such
+	// as "metrics.k8s.io/v1beta1".
+	Groups map[string]error
+}
+
+func (e *GroupDiscoveryError) Error() string {
+	var groupVersions []string
+	for gv := range e.Groups {
+		groupVersions = append(groupVersions, gv)
+	}
+	sort.Strings(groupVersions)
+
+	errs := make([]string, len(groupVersions))
+	for i, gv := range groupVersions {
+		errs[i] = fmt.Sprintf("%s: %v", gv, e.Groups[gv])
+	}
+	return "unable to discover resources for group versions: " + strings.Join(errs, ", ")
+}
+
+// ServerResources returns the resources of every version of every API group,
+// including the legacy core group. Group versions are queried concurrently.
+//
+// Lists are ordered by group, as returned by ServerGroups, with the preferred
+// version of each group first.
+//
+// If some group versions fail, the lists that were successfully fetched are
+// returned along with a *GroupDiscoveryError.
+//
+//		lists, err := discovery.ServerResources(ctx)
+//		if err != nil {
+//			if _, ok := err.(*k8s.GroupDiscoveryError); !ok {
+//				// handle error
+//			}
+//			// some groups are unavailable, but lists can still be used
+//		}
+//
+func (d *Discovery) ServerResources(ctx context.Context) ([]*metav1.APIResourceList, error) {
+	groups, err := d.ServerGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type groupVersion struct{ group, version string }
+	var gvs []groupVersion
+	for _, group := range groups.Groups {
+		for _, version := range preferredVersionOrder(group) {
+			gvs = append(gvs, groupVersion{group.GetName(), version})
+		}
+	}
+
+	lists := make([]*metav1.APIResourceList, len(gvs))
+	errs := make([]error, len(gvs))
+
+	var wg sync.WaitGroup
+	for i, gv := range gvs {
+		wg.Add(1)
+		go func(i int, group, version string) {
+			defer wg.Done()
+			lists[i], errs[i] = d.APIResources(ctx, group, version)
+		}(i, gv.group, gv.version)
+	}
+	wg.Wait()
+
+	var (
+		resources []*metav1.APIResourceList
+		discErr   *GroupDiscoveryError
+	)
+	for i, gv := range gvs {
+		if errs[i] == nil {
+			resources = append(resources, lists[i])
+			continue
+		}
+		if discErr == nil {
+			discErr = &GroupDiscoveryError{Groups: map[string]error{}}
+		}
+		discErr.Groups[path.Join(gv.group, gv.version)] = errs[i]
+	}
+	if discErr != nil {
+		return resources, discErr
+	}
+	return resources, nil
+}
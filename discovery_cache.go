@@ -0,0 +1,198 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// discoveryCache caches discovery responses in memory and optionally on disk.
+//
+// Entries are keyed by URL path and the requested content type. Disk writes are
+// best effort, a failure to persist a response doesn't fail the request.
+type discoveryCache struct {
+	// Directory for this API server's responses. Empty if only caching in memory.
+	dir string
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	ETag        string    `json:"etag,omitempty"`
+	ContentType string    `json:"contentType"`
+	Body        []byte    `json:"body"`
+	Fetched     time.Time `json:"fetched"`
+}
+
+var invalidCacheDirChars = regexp.MustCompile(`[^a-zA-Z0-9.\-]`)
+
+// cacheDirName converts an API server endpoint to a directory name, the same way
+// kubectl does. For example "https://10.0.0.1:6443" becomes "10.0.0.1_6443".
+func cacheDirName(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		endpoint = u.Host + u.Path
+	}
+	return invalidCacheDirChars.ReplaceAllString(endpoint, "_")
+}
+
+func newDiscoveryCache(endpoint, dir string, ttl time.Duration) *discoveryCache {
+	if dir != "" {
+		dir = filepath.Join(dir, cacheDirName(endpoint))
+	}
+	return &discoveryCache{dir: dir, ttl: ttl, entries: map[string]cacheEntry{}}
+}
+
+func (dc *discoveryCache) filename(p, contentType string) string {
+	name := "cache.json"
+	if contentType == contentTypePB {
+		name = "cache.pb"
+	}
+	return filepath.Join(dc.dir, filepath.FromSlash(p), name)
+}
+
+func (dc *discoveryCache) load(p, contentType string) (cacheEntry, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if e, ok := dc.entries[p+" "+contentType]; ok {
+		return e, true
+	}
+	if dc.dir == "" {
+		return cacheEntry{}, false
+	}
+	data, err := ioutil.ReadFile(dc.filename(p, contentType))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return cacheEntry{}, false
+	}
+	dc.entries[p+" "+contentType] = e
+	return e, true
+}
+
+func (dc *discoveryCache) store(p, contentType string, e cacheEntry) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.entries[p+" "+contentType] = e
+	if dc.dir == "" {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fp := dc.filename(p, contentType)
+	if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+		return
+	}
+	// Write to a temporary file then rename, so concurrent readers never observe
+	// a partially written file.
+	f, err := ioutil.TempFile(filepath.Dir(fp), ".cache")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+	if err := os.Rename(f.Name(), fp); err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// expire marks the in-memory entries for a path as stale, so they're
+// revalidated with the API server on next use.
+func (dc *discoveryCache) expire(p string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	for _, contentType := range []string{contentTypePB, contentTypeJSON} {
+		if e, ok := dc.entries[p+" "+contentType]; ok {
+			e.Fetched = time.Time{}
+			dc.entries[p+" "+contentType] = e
+		}
+	}
+}
+
+func (dc *discoveryCache) invalidate() error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.entries = map[string]cacheEntry{}
+	if dc.dir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(dc.dir); err != nil {
+		return fmt.Errorf("remove discovery cache: %v", err)
+	}
+	return nil
+}
+
+func (dc *discoveryCache) get(ctx context.Context, c *Client, p string, resp interface{}) error {
+	contentType := c.contentTypeFor(resp, nil)
+	err := dc.getContentType(ctx, c, p, resp, contentType)
+	if contentType == contentTypePB && unsupportedContentType(err) {
+		return dc.getContentType(ctx, c, p, resp, contentTypeJSON)
+	}
+	return err
+}
+
+func (dc *discoveryCache) getContentType(ctx context.Context, c *Client, p string, resp interface{}, contentType string) error {
+	e, ok := dc.load(p, contentType)
+	if ok && time.Since(e.Fetched) < dc.ttl {
+		return unmarshal(e.Body, e.ContentType, resp)
+	}
+
+	req, err := c.newRequest(ctx, "GET", urlForPath(c.Endpoint, p), nil)
+	if err != nil {
+		return fmt.Errorf("new request: %v", err)
+	}
+	req.Header.Set("Accept", contentType)
+	if ok && e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+
+	re, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("performing request: %v", err)
+	}
+	defer re.Body.Close()
+
+	body, err := ioutil.ReadAll(re.Body)
+	if err != nil {
+		return fmt.Errorf("read body: %v", err)
+	}
+
+	if ok && re.StatusCode == http.StatusNotModified {
+		e.Fetched = time.Now()
+	} else {
+		respCT := re.Header.Get("Content-Type")
+		if err := checkStatusCode(respCT, re.StatusCode, body); err != nil {
+			return err
+		}
+		e = cacheEntry{
+			ETag:        re.Header.Get("ETag"),
+			ContentType: respCT,
+			Body:        body,
+			Fetched:     time.Now(),
+		}
+	}
+	if err := unmarshal(e.Body, e.ContentType, resp); err != nil {
+		return err
+	}
+	dc.store(p, contentType, e)
+	return nil
+}
@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCacheDirName(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"https://10.0.0.1:6443", "10.0.0.1_6443"},
+		{"https://example.com/k8s/", "example.com_k8s_"},
+		{"localhost:8080", "localhost_8080"},
+	}
+	for _, test := range tests {
+		if got := cacheDirName(test.endpoint); got != test.want {
+			t.Errorf("cacheDirName(%q): wanted=%q, got=%q", test.endpoint, test.want, got)
+		}
+	}
+}
+
+func TestDiscoveryCache(t *testing.T) {
+	var requests, notModified int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"major":"1","minor":"13"}`))
+	}))
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "k8s-discovery-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	c := &Client{Endpoint: s.URL}
+
+	d := NewCachedDiscoveryClient(c, dir, time.Hour)
+	for i := 0; i < 3; i++ {
+		v, err := d.Version(ctx)
+		if err != nil {
+			t.Fatalf("get version: %v", err)
+		}
+		if v.Minor != "13" {
+			t.Errorf("wanted minor version %q, got %q", "13", v.Minor)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected responses to be cached in memory, got %d requests", requests)
+	}
+
+	// A new client should read the response from disk.
+	if _, err := NewCachedDiscoveryClient(c, dir, time.Hour).Version(ctx); err != nil {
+		t.Fatalf("get version: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected responses to be cached on disk, got %d requests", requests)
+	}
+
+	// An expired entry should be revalidated using its ETag.
+	if _, err := NewCachedDiscoveryClient(c, dir, 0).Version(ctx); err != nil {
+		t.Fatalf("get version: %v", err)
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("expected a conditional request, got requests=%d not-modified=%d", requests, notModified)
+	}
+
+	if err := d.Invalidate(); err != nil {
+		t.Fatalf("invalidate cache: %v", err)
+	}
+	if _, err := d.Version(ctx); err != nil {
+		t.Fatalf("get version: %v", err)
+	}
+	if requests != 3 || notModified != 1 {
+		t.Errorf("expected an unconditional request, got requests=%d not-modified=%d", requests, notModified)
+	}
+}
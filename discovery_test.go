@@ -27,4 +27,66 @@ func TestDiscovery(t *testing.T) {
 	if _, err := client.APIResources(ctx, "extensions", "v1beta1"); err != nil {
 		t.Errorf("list api group resources: %v", err)
 	}
+
+	if _, err := client.APIVersions(ctx); err != nil {
+		t.Errorf("list core api versions: %v", err)
+	}
+
+	if _, err := client.APIResources(ctx, "", "v1"); err != nil {
+		t.Errorf("list core api resources: %v", err)
+	}
+
+	lists, err := client.ServerResources(ctx)
+	if err != nil {
+		t.Errorf("list server resources: %v", err)
+	}
+	if len(lists) == 0 || lists[0].GetGroupVersion() != "v1" {
+		t.Errorf("expected core group version to be listed first")
+	}
+}
+
+func TestRESTMapper(t *testing.T) {
+	mapper := k8s.NewRESTMapper(k8s.NewDiscoveryClient(newTestClient(t)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mapping, err := mapper.RESTMapping(ctx, k8s.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+	if err != nil {
+		t.Fatalf("map configmap kind: %v", err)
+	}
+	if mapping.Resource.Resource != "configmaps" || !mapping.Namespaced {
+		t.Errorf("unexpected mapping for configmaps: %#v", mapping)
+	}
+
+	if _, err := mapper.ResourceFor(ctx, "deploy"); err != nil {
+		t.Errorf("map deployment short name: %v", err)
+	}
+	if _, err := mapper.ResourceFor(ctx, "idontexist"); err == nil {
+		t.Errorf("expected error mapping unknown resource")
+	}
+}
+
+func TestOpenAPISchema(t *testing.T) {
+	client := k8s.NewDiscoveryClient(newTestClient(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	schema, err := client.OpenAPISchema(ctx)
+	if err != nil {
+		t.Fatalf("get openapi schema: %v", err)
+	}
+
+	cm := new(k8s.Unstructured)
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetName("my-configmap")
+	cm.Object["data"] = map[string]interface{}{"foo": "bar"}
+	if err := schema.ValidateUnstructured(cm); err != nil {
+		t.Errorf("validate configmap: %v", err)
+	}
+
+	cm.Object["dat"] = map[string]interface{}{"foo": "bar"}
+	if err := schema.ValidateUnstructured(cm); err == nil {
+		t.Errorf("expected unknown field to fail validation")
+	}
 }
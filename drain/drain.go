@@ -0,0 +1,218 @@
+/*
+Package drain implements cordoning and draining of Kubernetes nodes.
+
+Pods are removed using the eviction API, so PodDisruptionBudgets are respected.
+
+	client, err := k8s.NewInClusterClient()
+	if err != nil {
+		// handle error
+	}
+	if err := drain.Drain(ctx, client, "my-node", nil); err != nil {
+		// handle error
+	}
+
+*/
+package drain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+)
+
+// Annotation the kubelet sets on mirror pods of static pods. Mirror pods can't be
+// evicted through the API server.
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// Options configures a drain. A nil *Options uses the defaults described by each
+// field.
+type Options struct {
+	// GracePeriod overrides the termination grace period of evicted pods. If
+	// negative or zero, each pod's own grace period is used.
+	GracePeriod time.Duration
+
+	// Timeout is the maximum amount of time to spend evicting pods and waiting
+	// for them to be deleted. Defaults to 5 minutes.
+	Timeout time.Duration
+
+	// RetryInterval is the time to wait before retrying an eviction rejected
+	// due to a PodDisruptionBudget. Defaults to 5 seconds.
+	RetryInterval time.Duration
+}
+
+func (o *Options) gracePeriod() time.Duration {
+	if o == nil || o.GracePeriod <= 0 {
+		return -1
+	}
+	return o.GracePeriod
+}
+
+func (o *Options) timeout() time.Duration {
+	if o == nil || o.Timeout <= 0 {
+		return 5 * time.Minute
+	}
+	return o.Timeout
+}
+
+func (o *Options) retryInterval() time.Duration {
+	if o == nil || o.RetryInterval <= 0 {
+		return 5 * time.Second
+	}
+	return o.RetryInterval
+}
+
+// Cordon marks a node as unschedulable. The node is updated in place with the
+// response from the API server.
+func Cordon(ctx context.Context, c k8s.Writer, node *corev1.Node) error {
+	return setUnschedulable(ctx, c, node, true)
+}
+
+// Uncordon marks a node as schedulable. The node is updated in place with the
+// response from the API server.
+func Uncordon(ctx context.Context, c k8s.Writer, node *corev1.Node) error {
+	return setUnschedulable(ctx, c, node, false)
+}
+
+func setUnschedulable(ctx context.Context, c k8s.Writer, node *corev1.Node, unschedulable bool) error {
+	if node.Spec == nil {
+		node.Spec = &corev1.NodeSpec{}
+	}
+	if node.Spec.GetUnschedulable() == unschedulable {
+		return nil
+	}
+	// Patch rather than update the node so concurrent changes made by the
+	// kubelet, such as status updates, don't cause conflicts.
+	patch := []byte(fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable))
+	if err := c.Patch(ctx, node, k8s.PatchMerge, patch); err != nil {
+		return fmt.Errorf("patch node %s: %v", node.GetMetadata().GetName(), err)
+	}
+	return nil
+}
+
+// Drain cordons a node then evicts all pods running on it, waiting for the pods
+// to be deleted. DaemonSet pods and mirror pods are skipped since they would be
+// immediately recreated on the same node.
+//
+// Evictions that would violate a PodDisruptionBudget are retried until the drain
+// times out.
+func Drain(ctx context.Context, c *k8s.Client, nodeName string, opts *Options) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout())
+	defer cancel()
+
+	var node corev1.Node
+	if err := c.Get(ctx, "", nodeName, &node); err != nil {
+		return fmt.Errorf("get node %s: %v", nodeName, err)
+	}
+	if err := Cordon(ctx, c, &node); err != nil {
+		return err
+	}
+
+	pods, err := Pods(ctx, c, nodeName)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if err := evict(ctx, c, pod, opts); err != nil {
+			return err
+		}
+	}
+	for _, pod := range pods {
+		if err := waitForDelete(ctx, c, pod, opts.retryInterval()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pods returns the pods scheduled to a node which must be evicted to drain it.
+func Pods(ctx context.Context, c k8s.Reader, nodeName string) ([]*corev1.Pod, error) {
+	var pods corev1.PodList
+	fieldSelector := k8s.QueryParam("fieldSelector", "spec.nodeName="+nodeName)
+	if err := c.List(ctx, k8s.AllNamespaces, &pods, fieldSelector); err != nil {
+		return nil, fmt.Errorf("list pods on node %s: %v", nodeName, err)
+	}
+	var evictable []*corev1.Pod
+	for _, pod := range pods.Items {
+		if skipPod(pod) {
+			continue
+		}
+		evictable = append(evictable, pod)
+	}
+	return evictable, nil
+}
+
+// skipPod determines if a pod shouldn't be evicted when draining a node.
+func skipPod(pod *corev1.Pod) bool {
+	meta := pod.GetMetadata()
+	if _, ok := meta.GetAnnotations()[mirrorPodAnnotation]; ok {
+		return true
+	}
+	for _, ref := range meta.GetOwnerReferences() {
+		if ref.GetController() && ref.GetKind() == "DaemonSet" {
+			return true
+		}
+	}
+	switch pod.GetStatus().GetPhase() {
+	case "Succeeded", "Failed":
+		// Terminated pods don't need to be evicted to drain the node.
+		return true
+	}
+	return false
+}
+
+func evict(ctx context.Context, c *k8s.Client, pod *corev1.Pod, opts *Options) error {
+	for {
+		err := c.Evict(ctx, pod, opts.gracePeriod())
+		if err == nil {
+			return nil
+		}
+		apiErr, ok := err.(*k8s.APIError)
+		if !ok {
+			return fmt.Errorf("evict pod %s/%s: %v", pod.Metadata.GetNamespace(), pod.Metadata.GetName(), err)
+		}
+		switch apiErr.Code {
+		case http.StatusNotFound:
+			// Pod has already been deleted.
+			return nil
+		case http.StatusTooManyRequests:
+			// Eviction would violate a PodDisruptionBudget. Try again later.
+		default:
+			return fmt.Errorf("evict pod %s/%s: %v", pod.Metadata.GetNamespace(), pod.Metadata.GetName(), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("evict pod %s/%s: %v", pod.Metadata.GetNamespace(), pod.Metadata.GetName(), ctx.Err())
+		case <-time.After(opts.retryInterval()):
+		}
+	}
+}
+
+// waitForDelete polls the API server until the pod no longer exists, or has
+// been replaced by a pod with the same name but a different UID.
+func waitForDelete(ctx context.Context, c k8s.Reader, pod *corev1.Pod, interval time.Duration) error {
+	namespace, name := pod.Metadata.GetNamespace(), pod.Metadata.GetName()
+	for {
+		var got corev1.Pod
+		err := c.Get(ctx, namespace, name, &got)
+		if err != nil {
+			if apiErr, ok := err.(*k8s.APIError); ok && apiErr.Code == http.StatusNotFound {
+				return nil
+			}
+			return fmt.Errorf("get pod %s/%s: %v", namespace, name, err)
+		}
+		if got.Metadata.GetUid() != pod.Metadata.GetUid() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for pod %s/%s to be deleted: %v", namespace, name, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
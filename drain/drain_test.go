@@ -0,0 +1,279 @@
+package drain
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	policyv1beta1 "github.com/ericchiang/k8s/apis/policy/v1beta1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+func TestSkipPod(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want bool
+	}{
+		{
+			name: "plain-pod",
+			pod: &corev1.Pod{
+				Metadata: &metav1.ObjectMeta{Name: k8s.String("my-pod")},
+				Status:   &corev1.PodStatus{Phase: k8s.String("Running")},
+			},
+			want: false,
+		},
+		{
+			name: "mirror-pod",
+			pod: &corev1.Pod{
+				Metadata: &metav1.ObjectMeta{
+					Name:        k8s.String("my-pod"),
+					Annotations: map[string]string{mirrorPodAnnotation: "abc"},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "daemonset-pod",
+			pod: &corev1.Pod{
+				Metadata: &metav1.ObjectMeta{
+					Name: k8s.String("my-pod"),
+					OwnerReferences: []*metav1.OwnerReference{
+						{Kind: k8s.String("DaemonSet"), Controller: k8s.Bool(true)},
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "replicaset-pod",
+			pod: &corev1.Pod{
+				Metadata: &metav1.ObjectMeta{
+					Name: k8s.String("my-pod"),
+					OwnerReferences: []*metav1.OwnerReference{
+						{Kind: k8s.String("ReplicaSet"), Controller: k8s.Bool(true)},
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "completed-pod",
+			pod: &corev1.Pod{
+				Metadata: &metav1.ObjectMeta{Name: k8s.String("my-pod")},
+				Status:   &corev1.PodStatus{Phase: k8s.String("Succeeded")},
+			},
+			want: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := skipPod(test.pod); got != test.want {
+				t.Errorf("wanted=%t, got=%t", test.want, got)
+			}
+		})
+	}
+}
+
+// rejectedEvictions signals the first eviction rejected by a disruption budget.
+type rejectedEvictions struct {
+	base http.RoundTripper
+	once sync.Once
+	c    chan struct{}
+}
+
+func (r *rejectedEvictions) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		r.once.Do(func() { close(r.c) })
+	}
+	return resp, err
+}
+
+func newPod(name, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String(name),
+			Namespace: k8s.String("default"),
+		},
+		Spec:   &corev1.PodSpec{NodeName: k8s.String(nodeName)},
+		Status: &corev1.PodStatus{Phase: k8s.String("Running")},
+	}
+}
+
+// poll calls f until it returns true or the test times out.
+func poll(t *testing.T, desc string, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", desc)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCordon(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	node := &corev1.Node{Metadata: &metav1.ObjectMeta{Name: k8s.String("node-1")}}
+	if err := client.Create(ctx, node); err != nil {
+		t.Fatalf("create node: %v", err)
+	}
+	// Modify the node so the local copy is stale.
+	current := new(corev1.Node)
+	if err := client.Get(ctx, "", "node-1", current); err != nil {
+		t.Fatalf("get node: %v", err)
+	}
+	current.Metadata.Labels = map[string]string{"zone": "a"}
+	if err := client.Update(ctx, current); err != nil {
+		t.Fatalf("update node: %v", err)
+	}
+
+	if err := Cordon(ctx, client, node); err != nil {
+		t.Fatalf("cordon: %v", err)
+	}
+	if !node.GetSpec().GetUnschedulable() || node.GetMetadata().GetLabels()["zone"] != "a" {
+		t.Errorf("expected node to be updated with the server's response, got %v", node)
+	}
+
+	if err := Uncordon(ctx, client, node); err != nil {
+		t.Fatalf("uncordon: %v", err)
+	}
+	if err := client.Get(ctx, "", "node-1", current); err != nil {
+		t.Fatalf("get node: %v", err)
+	}
+	if current.GetSpec().GetUnschedulable() {
+		t.Errorf("expected node to be uncordoned")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	rejected := &rejectedEvictions{base: client.Client.Transport, c: make(chan struct{})}
+	client.Client = &http.Client{Transport: rejected}
+	ctx := context.Background()
+
+	node := &corev1.Node{Metadata: &metav1.ObjectMeta{Name: k8s.String("node-1")}}
+	if err := client.Create(ctx, node); err != nil {
+		t.Fatalf("create node: %v", err)
+	}
+
+	// Covered by a disruption budget which initially allows no disruptions.
+	web := newPod("web", "node-1")
+	web.Metadata.Labels = map[string]string{"app": "web"}
+	// Deleted once its finalizer is removed.
+	worker := newPod("worker", "node-1")
+	worker.Metadata.Finalizers = []string{"example.com/cleanup"}
+	// Skipped by the drain.
+	agent := newPod("agent", "node-1")
+	agent.Metadata.OwnerReferences = []*metav1.OwnerReference{
+		{Kind: k8s.String("DaemonSet"), Controller: k8s.Bool(true)},
+	}
+	other := newPod("other", "node-2")
+	for _, pod := range []*corev1.Pod{web, worker, agent, other} {
+		if err := client.Create(ctx, pod); err != nil {
+			t.Fatalf("create pod: %v", err)
+		}
+	}
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("web"),
+			Namespace: k8s.String("default"),
+		},
+		Spec: &policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: &policyv1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: k8s.Int32(0)},
+	}
+	if err := client.Create(ctx, pdb); err != nil {
+		t.Fatalf("create disruption budget: %v", err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		opts := &Options{Timeout: 10 * time.Second, RetryInterval: 10 * time.Millisecond}
+		errc <- Drain(ctx, client, "node-1", opts)
+	}()
+
+	select {
+	case <-rejected.c:
+	case err := <-errc:
+		t.Fatalf("drain returned before an eviction was rejected: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for an eviction to be rejected")
+	}
+	if err := client.Get(ctx, "default", "web", new(corev1.Pod)); err != nil {
+		t.Fatalf("expected pod to survive a rejected eviction: %v", err)
+	}
+
+	// Act as the disruption controller and allow a disruption.
+	if err := client.Get(ctx, "default", "web", pdb); err != nil {
+		t.Fatalf("get disruption budget: %v", err)
+	}
+	pdb.Status.DisruptionsAllowed = k8s.Int32(1)
+	if err := client.Update(ctx, pdb); err != nil {
+		t.Fatalf("update disruption budget: %v", err)
+	}
+
+	// The drain waits for the worker until its finalizer is removed.
+	poll(t, "worker to be marked for deletion", func() bool {
+		if err := client.Get(ctx, "default", "worker", worker); err != nil {
+			t.Fatalf("get pod: %v", err)
+		}
+		return worker.Metadata.DeletionTimestamp != nil
+	})
+	select {
+	case err := <-errc:
+		t.Fatalf("drain returned before pods were deleted: %v", err)
+	default:
+	}
+	worker.Metadata.Finalizers = nil
+	if err := client.Update(ctx, worker); err != nil {
+		t.Fatalf("remove finalizer: %v", err)
+	}
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("drain: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for drain")
+	}
+
+	if err := client.Get(ctx, "", "node-1", node); err != nil {
+		t.Fatalf("get node: %v", err)
+	}
+	if !node.GetSpec().GetUnschedulable() {
+		t.Errorf("expected node to be cordoned")
+	}
+	for _, name := range []string{"web", "worker"} {
+		err := client.Get(ctx, "default", name, new(corev1.Pod))
+		if apiErr, ok := err.(*k8s.APIError); !ok || apiErr.Code != http.StatusNotFound {
+			t.Errorf("expected pod %s to be evicted, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"agent", "other"} {
+		if err := client.Get(ctx, "default", name, new(corev1.Pod)); err != nil {
+			t.Errorf("expected pod %s not to be evicted: %v", name, err)
+		}
+	}
+	if err := client.Get(ctx, "default", "web", pdb); err != nil {
+		t.Fatalf("get disruption budget: %v", err)
+	}
+	if n := pdb.GetStatus().GetDisruptionsAllowed(); n != 0 {
+		t.Errorf("expected eviction to consume the allowed disruption, got %d allowed", n)
+	}
+}
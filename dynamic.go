@@ -0,0 +1,176 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// GroupVersionResource identifies a resource type served by the API server, such
+// as the "deployments" resource in the "apps/v1" group version. The core API
+// group is represented by an empty Group.
+type GroupVersionResource struct {
+	Group    string
+	Version  string
+	Resource string
+}
+
+func (gvr GroupVersionResource) String() string {
+	if gvr.Group == "" {
+		return gvr.Version + "/" + gvr.Resource
+	}
+	return gvr.Group + "/" + gvr.Version + "/" + gvr.Resource
+}
+
+// Dynamic is a client that operates on Unstructured objects rather than
+// registered Go types. Resources are identified by their group, version and
+// resource name, which can be determined at runtime through discovery.
+//
+// Namespaces are always taken literally. An empty namespace addresses
+// cluster scoped resources for Get, Create, Update and Delete, and all
+// namespaces for List and Watch.
+//
+//		client := k8s.NewDynamicClient(c)
+//		gvr := k8s.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+//
+//		var widgets k8s.UnstructuredList
+//		if err := client.List(ctx, gvr, "my-namespace", &widgets); err != nil {
+//			// handle error
+//		}
+//		for _, widget := range widgets.Items {
+//			fmt.Println(widget.GetName())
+//		}
+//
+type Dynamic struct {
+	client *Client
+}
+
+// NewDynamicClient returns a client for Unstructured objects.
+func NewDynamicClient(c *Client) *Dynamic {
+	return &Dynamic{c}
+}
+
+func (d *Dynamic) url(gvr GroupVersionResource, namespace, name string, options ...Option) (string, error) {
+	if gvr.Version == "" || gvr.Resource == "" {
+		return "", errors.New("resource version and name must be provided")
+	}
+	return urlFor(d.client.Endpoint, gvr.Group, gvr.Version, namespace, gvr.Resource, name, options...), nil
+}
+
+func (d *Dynamic) objectURL(gvr GroupVersionResource, obj *Unstructured, withName bool, options ...Option) (string, error) {
+	name := ""
+	if withName {
+		if name = obj.GetName(); name == "" {
+			return "", errors.New("no resource name provided")
+		}
+	}
+	return d.url(gvr, obj.GetNamespace(), name, options...)
+}
+
+// Create creates an object. The namespace is determined by the object's metadata.
+// The result is unmarshaled into obj.
+func (d *Dynamic) Create(ctx context.Context, gvr GroupVersionResource, obj *Unstructured, options ...Option) error {
+	url, err := d.objectURL(gvr, obj, false, options...)
+	if err != nil {
+		return err
+	}
+	return d.client.do(ctx, "POST", url, obj, obj)
+}
+
+// Update replaces an existing object. The result is unmarshaled into obj.
+func (d *Dynamic) Update(ctx context.Context, gvr GroupVersionResource, obj *Unstructured, options ...Option) error {
+	url, err := d.objectURL(gvr, obj, true, options...)
+	if err != nil {
+		return err
+	}
+	return d.client.do(ctx, "PUT", url, obj, obj)
+}
+
+// Patch applies a patch document to an object, identified by the name and
+// namespace in its metadata. The result is unmarshaled into obj.
+func (d *Dynamic) Patch(ctx context.Context, gvr GroupVersionResource, obj *Unstructured, pt PatchType, data []byte, options ...Option) error {
+	url, err := d.objectURL(gvr, obj, true, options...)
+	if err != nil {
+		return err
+	}
+	return d.client.do(ctx, "PATCH", url, &patch{pt, data}, obj)
+}
+
+// Delete deletes an object. The same options as Client.Delete are supported.
+func (d *Dynamic) Delete(ctx context.Context, gvr GroupVersionResource, obj *Unstructured, options ...Option) error {
+	url, err := d.objectURL(gvr, obj, true, options...)
+	if err != nil {
+		return err
+	}
+	o := &deleteOptions{
+		Kind:              "DeleteOptions",
+		APIVersion:        "v1",
+		PropagationPolicy: "Background",
+	}
+	for _, option := range options {
+		option.updateDelete(obj, o)
+	}
+	return d.client.do(ctx, "DELETE", url, o, nil)
+}
+
+// Get reads an object by name and unmarshals it into resp.
+func (d *Dynamic) Get(ctx context.Context, gvr GroupVersionResource, namespace, name string, resp *Unstructured, options ...Option) error {
+	if name == "" {
+		return errors.New("no resource name provided")
+	}
+	url, err := d.url(gvr, namespace, name, readOptions(options)...)
+	if err != nil {
+		return err
+	}
+	return d.client.do(ctx, "GET", url, nil, resp)
+}
+
+// List lists objects in a namespace, or across all namespaces if the namespace
+// is empty.
+func (d *Dynamic) List(ctx context.Context, gvr GroupVersionResource, namespace string, resp *UnstructuredList, options ...Option) error {
+	url, err := d.url(gvr, namespace, "", readOptions(options)...)
+	if err != nil {
+		return err
+	}
+	return d.client.do(ctx, "GET", url, nil, resp)
+}
+
+// Watch creates a watch on objects in a namespace, or across all namespaces if
+// the namespace is empty. Events should be decoded into *Unstructured values.
+//
+//		watcher, err := client.Watch(ctx, gvr, "my-namespace")
+//		if err != nil {
+//			// handle error
+//		}
+//		defer watcher.Close()
+//
+//		for {
+//			obj := new(k8s.Unstructured)
+//			eventType, err := watcher.Next(obj)
+//			if err != nil {
+//				// watcher encountered and error, exit or create a new watcher
+//			}
+//			fmt.Println(eventType, obj.GetName())
+//		}
+//
+func (d *Dynamic) Watch(ctx context.Context, gvr GroupVersionResource, namespace string, options ...Option) (*Watcher, error) {
+	url, err := d.url(gvr, namespace, "", readOptions(options)...)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(url, "?") {
+		url = url + "&watch=true"
+	} else {
+		url = url + "?watch=true"
+	}
+
+	resp, err := d.client.watch(ctx, url, contentTypeJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{&watcherJSON{
+		d: json.NewDecoder(resp.Body),
+		c: resp.Body,
+	}}, nil
+}
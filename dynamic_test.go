@@ -0,0 +1,54 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+)
+
+func TestDynamicClient(t *testing.T) {
+	withNamespace(t, func(client *k8s.Client, namespace string) {
+		d := k8s.NewDynamicClient(client)
+		gvr := k8s.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+		cm := new(k8s.Unstructured)
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetName("my-configmap")
+		cm.SetNamespace(namespace)
+		cm.Object["data"] = map[string]interface{}{"foo": "bar"}
+
+		if err := d.Create(context.TODO(), gvr, cm); err != nil {
+			t.Fatalf("create configmap: %v", err)
+		}
+		if cm.GetUID() == "" {
+			t.Errorf("expected create to populate uid")
+		}
+
+		cm.SetLabels(map[string]string{"hello": "world"})
+		if err := d.Update(context.TODO(), gvr, cm); err != nil {
+			t.Fatalf("update configmap: %v", err)
+		}
+
+		got := new(k8s.Unstructured)
+		if err := d.Get(context.TODO(), gvr, namespace, "my-configmap", got); err != nil {
+			t.Fatalf("get configmap: %v", err)
+		}
+		if got.GetLabels()["hello"] != "world" {
+			t.Errorf("expected label to be set, got %v", got.GetLabels())
+		}
+
+		var list k8s.UnstructuredList
+		if err := d.List(context.TODO(), gvr, namespace, &list); err != nil {
+			t.Fatalf("list configmaps: %v", err)
+		}
+		if len(list.Items) != 1 {
+			t.Errorf("expected 1 configmap, got %d", len(list.Items))
+		}
+
+		if err := d.Delete(context.TODO(), gvr, cm); err != nil {
+			t.Fatalf("delete configmap: %v", err)
+		}
+	})
+}
@@ -16,6 +16,7 @@ func init() {
 }
 
 type MyResource struct {
+	k8s.TypeMeta
 	Metadata *metav1.ObjectMeta `json:"metadata"`
 	Foo      string             `json:"foo"`
 	Bar      int                `json:"bar"`
@@ -26,6 +27,7 @@ func (m *MyResource) GetMetadata() *metav1.ObjectMeta {
 }
 
 type MyResourceList struct {
+	k8s.TypeMeta
 	Metadata *metav1.ListMeta `json:"metadata"`
 	Items    []MyResource     `json:"items"`
 }
@@ -0,0 +1,121 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/runtime"
+	"github.com/golang/protobuf/proto"
+)
+
+// Protobuf wire format used by the API server.
+//
+// See: https://github.com/kubernetes/community/blob/master/contributors/design-proposals/api-machinery/protobuf.md
+
+const (
+	contentTypePB   = "application/vnd.kubernetes.protobuf"
+	contentTypeJSON = "application/json"
+)
+
+var magicBytes = []byte{0x6b, 0x38, 0x73, 0x00}
+
+func marshalPB(msg proto.Message) ([]byte, error) {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	body, err := proto.Marshal(&runtime.Unknown{Raw: payload})
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, magicBytes...), body...), nil
+}
+
+func unmarshalPB(data []byte, msg proto.Message) error {
+	if !bytes.HasPrefix(data, magicBytes) {
+		return errors.New("payload is not a kubernetes protobuf object")
+	}
+	var u runtime.Unknown
+	if err := proto.Unmarshal(data[len(magicBytes):], &u); err != nil {
+		return fmt.Errorf("unmarshal unknown: %v", err)
+	}
+	return proto.Unmarshal(u.Raw, msg)
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return mt
+}
+
+// acceptsPB reports if a request accepts protobuf responses. Like the API
+// server, JSON is used unless protobuf is explicitly requested.
+func acceptsPB(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType(strings.TrimSpace(accept)) == contentTypePB {
+			return true
+		}
+	}
+	return false
+}
+
+// decode decodes a request body based on its content type.
+func decode(r *http.Request, body []byte, obj interface{}) error {
+	switch ct := mediaType(r.Header.Get("Content-Type")); ct {
+	case contentTypePB:
+		msg, ok := obj.(proto.Message)
+		if !ok {
+			return fmt.Errorf("%T cannot be decoded from protobuf", obj)
+		}
+		return unmarshalPB(body, msg)
+	case contentTypeJSON, "":
+		return json.Unmarshal(body, obj)
+	default:
+		return fmt.Errorf("unsupported content type %q", ct)
+	}
+}
+
+// encode encodes a response in the format requested by the client.
+func encode(r *http.Request, obj interface{}) (contentType string, data []byte, err error) {
+	if msg, ok := obj.(proto.Message); ok && acceptsPB(r) {
+		data, err := marshalPB(msg)
+		return contentTypePB, data, err
+	}
+	data, err = json.Marshal(obj)
+	return contentTypeJSON, data, err
+}
+
+// encodeEvent encodes a watch event. JSON events are newline delimited, and
+// protobuf events are prefixed by their length.
+func encodeEvent(pb bool, eventType string, obj interface{}) ([]byte, error) {
+	if !pb {
+		data, err := json.Marshal(struct {
+			Type   string      `json:"type"`
+			Object interface{} `json:"object"`
+		}{eventType, obj})
+		return append(data, '\n'), err
+	}
+	raw, err := marshalPB(obj.(proto.Message))
+	if err != nil {
+		return nil, err
+	}
+	event, err := proto.Marshal(&metav1.WatchEvent{
+		Type:   &eventType,
+		Object: &runtime.RawExtension{Raw: raw},
+	})
+	if err != nil {
+		return nil, err
+	}
+	frame := make([]byte, 4, 4+len(event))
+	binary.BigEndian.PutUint32(frame, uint32(len(event)))
+	return append(frame, event...), nil
+}
@@ -0,0 +1,149 @@
+package fake
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/k8s"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+// Discovery information is derived from the types registered with the k8s
+// package, so only API groups that have been imported are served. Resources
+// whose type has a Status field are reported as having a status subresource.
+
+var verbs = []string{"create", "delete", "get", "list", "patch", "update", "watch"}
+
+// serveDiscovery serves "/api", "/apis" and the resource lists of each group
+// version. It reports false if the path isn't a discovery path.
+func (s *Server) serveDiscovery(w http.ResponseWriter, r *http.Request) bool {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	c := &call{s: s, w: w, r: r}
+	switch {
+	case len(parts) == 1 && parts[0] == "api":
+		c.write(http.StatusOK, &metav1.APIVersions{Versions: groupVersions("")})
+	case len(parts) == 1 && parts[0] == "apis":
+		list := &metav1.APIGroupList{}
+		for _, group := range groupNames() {
+			list.Groups = append(list.Groups, apiGroup(group))
+		}
+		c.write(http.StatusOK, list)
+	case len(parts) == 2 && parts[0] == "apis":
+		if len(groupVersions(parts[1])) == 0 {
+			return false
+		}
+		c.write(http.StatusOK, apiGroup(parts[1]))
+	case len(parts) == 2 && parts[0] == "api":
+		return c.writeResources("", parts[1])
+	case len(parts) == 3 && parts[0] == "apis":
+		return c.writeResources(parts[1], parts[2])
+	default:
+		return false
+	}
+	return true
+}
+
+func groupNames() []string {
+	var names []string
+	seen := map[string]bool{"": true}
+	for _, m := range k8s.RESTMappings() {
+		if !seen[m.Resource.Group] {
+			seen[m.Resource.Group] = true
+			names = append(names, m.Resource.Group)
+		}
+	}
+	return names
+}
+
+// groupVersions returns the versions of a group, most preferred first.
+func groupVersions(group string) []string {
+	var versions []string
+	seen := map[string]bool{}
+	for _, m := range k8s.RESTMappings() {
+		if m.Resource.Group == group && !seen[m.Resource.Version] {
+			seen[m.Resource.Version] = true
+			versions = append(versions, m.Resource.Version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versionPriority(versions[i]) > versionPriority(versions[j])
+	})
+	return versions
+}
+
+var kubeVersion = regexp.MustCompile(`^v(\d+)(?:(alpha|beta)(\d+))?$`)
+
+// versionPriority orders versions the way the API server does, with stable
+// versions preferred over beta and alpha versions.
+func versionPriority(v string) int {
+	m := kubeVersion.FindStringSubmatch(v)
+	if m == nil {
+		return 0
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[3])
+	level := 3
+	switch m[2] {
+	case "beta":
+		level = 2
+	case "alpha":
+		level = 1
+	}
+	return level<<20 | major<<10 | minor
+}
+
+func apiGroup(group string) *metav1.APIGroup {
+	g := &metav1.APIGroup{Name: k8s.String(group)}
+	for _, v := range groupVersions(group) {
+		g.Versions = append(g.Versions, &metav1.GroupVersionForDiscovery{
+			GroupVersion: k8s.String(group + "/" + v),
+			Version:      k8s.String(v),
+		})
+	}
+	if len(g.Versions) > 0 {
+		g.PreferredVersion = g.Versions[0]
+	}
+	return g
+}
+
+func (c *call) writeResources(group, version string) bool {
+	list := &metav1.APIResourceList{GroupVersion: k8s.String(apiVersion(k8s.GroupVersionResource{Group: group, Version: version}))}
+	for _, m := range k8s.RESTMappings() {
+		if m.Resource.Group != group || m.Resource.Version != version {
+			continue
+		}
+		list.Resources = append(list.Resources, &metav1.APIResource{
+			Name:         k8s.String(m.Resource.Resource),
+			SingularName: k8s.String(strings.ToLower(m.Kind.Kind)),
+			Namespaced:   k8s.Bool(m.Namespaced),
+			Kind:         k8s.String(m.Kind.Kind),
+			Verbs:        &metav1.Verbs{Items: verbs},
+		})
+		if hasStatus(m.Kind) {
+			list.Resources = append(list.Resources, &metav1.APIResource{
+				Name:       k8s.String(m.Resource.Resource + "/status"),
+				Namespaced: k8s.Bool(m.Namespaced),
+				Kind:       k8s.String(m.Kind.Kind),
+				Verbs:      &metav1.Verbs{Items: []string{"get", "patch", "update"}},
+			})
+		}
+	}
+	if len(list.Resources) == 0 {
+		return false
+	}
+	c.write(http.StatusOK, list)
+	return true
+}
+
+func hasStatus(gvk k8s.GroupVersionKind) bool {
+	obj, ok := k8s.NewResource(gvk)
+	if !ok {
+		return false
+	}
+	_, ok = reflect.TypeOf(obj).Elem().FieldByName("Status")
+	return ok
+}
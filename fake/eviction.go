@@ -0,0 +1,133 @@
+package fake
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+
+	"github.com/ericchiang/k8s"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+// disruptionBudget holds the fields of a PodDisruptionBudget used to decide if
+// a pod can be evicted.
+type disruptionBudget struct {
+	Spec struct {
+		Selector *metav1.LabelSelector `json:"selector"`
+	} `json:"spec"`
+	Status struct {
+		DisruptionsAllowed int32 `json:"disruptionsAllowed"`
+	} `json:"status"`
+}
+
+func isPodDisruptionBudget(gvr k8s.GroupVersionResource) bool {
+	return gvr.Group == "policy" && gvr.Resource == "poddisruptionbudgets"
+}
+
+// evict handles the "eviction" subresource of pods. Pods covered by a
+// PodDisruptionBudget are only deleted if the budget allows a disruption, and
+// each eviction consumes one of the allowed disruptions.
+func (c *call) evict() {
+	if c.gvr.Group != "" || c.gvr.Resource != "pods" || c.r.Method != "POST" {
+		c.writeError(http.StatusNotFound, "NotFound", "the server could not find the requested resource")
+		return
+	}
+	body, err := ioutil.ReadAll(c.r.Body)
+	if err != nil {
+		c.writeError(http.StatusBadRequest, "BadRequest", "read body: %v", err)
+		return
+	}
+	var eviction struct {
+		DeleteOptions *deleteOptions `json:"deleteOptions"`
+	}
+	if err := json.Unmarshal(body, &eviction); err != nil {
+		c.writeError(http.StatusBadRequest, "BadRequest", "decode eviction: %v", err)
+		return
+	}
+	opts := eviction.DeleteOptions
+	if opts == nil {
+		opts = new(deleteOptions)
+	}
+
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	pod, ok := c.s.objects[c.key()]
+	if !ok {
+		c.writeNotFound()
+		return
+	}
+	meta, _ := metaFor(pod)
+	if !c.checkPreconditions(opts, meta) {
+		return
+	}
+
+	var (
+		budgetKey objectKey
+		budget    k8s.Resource
+		status    disruptionBudget
+	)
+	for key, obj := range c.s.objects {
+		if !isPodDisruptionBudget(key.gvr) || key.namespace != c.namespace {
+			continue
+		}
+		var b disruptionBudget
+		if data, err := json.Marshal(obj); err != nil || json.Unmarshal(data, &b) != nil {
+			continue
+		}
+		if !selectorMatches(b.Spec.Selector, meta.GetLabels()) {
+			continue
+		}
+		if budget != nil {
+			c.writeError(http.StatusInternalServerError, "InternalError", "This pod has more than one PodDisruptionBudget, which the eviction subresource does not support.")
+			return
+		}
+		budgetKey, budget, status = key, obj, b
+	}
+	if budget != nil && status.Status.DisruptionsAllowed <= 0 {
+		c.writeError(http.StatusTooManyRequests, "TooManyRequests", "Cannot evict pod as it would violate the pod's disruption budget.")
+		return
+	}
+	if opts.dryRun(c) {
+		c.write(http.StatusCreated, &metav1.Status{Status: k8s.String("Success")})
+		return
+	}
+	if budget != nil {
+		updated, err := withDisruptionsAllowed(budget, status.Status.DisruptionsAllowed-1)
+		if err != nil {
+			c.writeError(http.StatusInternalServerError, "InternalError", "update disruption budget: %v", err)
+			return
+		}
+		budgetMeta, _ := metaFor(updated)
+		budgetMeta.SetResourceVersion(c.s.nextResourceVersion())
+		c.s.objects[budgetKey] = updated
+		c.s.notify(budgetKey, k8s.EventModified, updated)
+	}
+	c.deleteObject(c.key(), pod)
+	c.write(http.StatusCreated, &metav1.Status{Status: k8s.String("Success")})
+}
+
+// withDisruptionsAllowed returns a copy of a PodDisruptionBudget with the
+// number of allowed disruptions in its status set.
+func withDisruptionsAllowed(budget k8s.Resource, n int32) (k8s.Resource, error) {
+	data, err := json.Marshal(budget)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{"disruptionsAllowed": n},
+	}
+	if data, err = json.Marshal(mergePatch(doc, patch)); err != nil {
+		return nil, err
+	}
+	updated := reflect.New(reflect.TypeOf(budget).Elem()).Interface().(k8s.Resource)
+	if err := json.Unmarshal(data, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
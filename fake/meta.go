@@ -0,0 +1,189 @@
+package fake
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/golang/protobuf/proto"
+)
+
+// objectMeta provides uniform access to the metadata the server manages, for
+// both registered types and Unstructured objects.
+type objectMeta interface {
+	GetName() string
+	GetGenerateName() string
+	GetNamespace() string
+	GetUid() string
+	GetResourceVersion() string
+	GetLabels() map[string]string
+	GetFinalizers() []string
+
+	SetName(name string)
+	SetNamespace(namespace string)
+	SetUid(uid string)
+	SetResourceVersion(rv string)
+
+	setCreationTimestamp(t time.Time)
+	copyCreationTimestamp(from objectMeta)
+
+	// Objects with finalizers are marked for deletion by setting their
+	// deletion timestamp.
+	deleting() bool
+	setDeletionTimestamp(t time.Time)
+	copyDeletionTimestamp(from objectMeta)
+}
+
+func metaFor(obj k8s.Resource) (objectMeta, error) {
+	if u, ok := obj.(*k8s.Unstructured); ok {
+		if u.Object == nil {
+			return nil, errors.New("object is empty")
+		}
+		return unstructuredMeta{u}, nil
+	}
+	m := obj.GetMetadata()
+	if m == nil {
+		return nil, errors.New("object has no metadata")
+	}
+	return typedMeta{m}, nil
+}
+
+type typedMeta struct {
+	*metav1.ObjectMeta
+}
+
+func (m typedMeta) SetName(name string)           { m.Name = k8s.String(name) }
+func (m typedMeta) SetNamespace(namespace string) { m.Namespace = k8s.String(namespace) }
+func (m typedMeta) SetUid(uid string)             { m.Uid = k8s.String(uid) }
+func (m typedMeta) SetResourceVersion(rv string)  { m.ResourceVersion = k8s.String(rv) }
+
+func (m typedMeta) setCreationTimestamp(t time.Time) {
+	seconds := t.Unix()
+	m.CreationTimestamp = &metav1.Time{Seconds: &seconds, Nanos: k8s.Int32(0)}
+}
+
+func (m typedMeta) copyCreationTimestamp(from objectMeta) {
+	if f, ok := from.(typedMeta); ok {
+		m.CreationTimestamp = f.CreationTimestamp
+	}
+}
+
+func (m typedMeta) deleting() bool { return m.DeletionTimestamp != nil }
+
+func (m typedMeta) setDeletionTimestamp(t time.Time) {
+	seconds := t.Unix()
+	m.DeletionTimestamp = &metav1.Time{Seconds: &seconds, Nanos: k8s.Int32(0)}
+}
+
+func (m typedMeta) copyDeletionTimestamp(from objectMeta) {
+	if f, ok := from.(typedMeta); ok {
+		m.DeletionTimestamp = f.DeletionTimestamp
+	}
+}
+
+type unstructuredMeta struct {
+	u *k8s.Unstructured
+}
+
+func (m unstructuredMeta) metadata() map[string]interface{} {
+	meta, ok := m.u.Object["metadata"].(map[string]interface{})
+	if !ok {
+		meta = map[string]interface{}{}
+		m.u.Object["metadata"] = meta
+	}
+	return meta
+}
+
+func (m unstructuredMeta) get(key string) string {
+	s, _ := m.metadata()[key].(string)
+	return s
+}
+
+func (m unstructuredMeta) GetName() string              { return m.get("name") }
+func (m unstructuredMeta) GetGenerateName() string      { return m.get("generateName") }
+func (m unstructuredMeta) GetNamespace() string         { return m.get("namespace") }
+func (m unstructuredMeta) GetUid() string               { return m.get("uid") }
+func (m unstructuredMeta) GetResourceVersion() string   { return m.get("resourceVersion") }
+func (m unstructuredMeta) GetLabels() map[string]string { return m.u.GetLabels() }
+
+func (m unstructuredMeta) GetFinalizers() []string {
+	list, _ := m.metadata()["finalizers"].([]interface{})
+	var finalizers []string
+	for _, f := range list {
+		if s, ok := f.(string); ok {
+			finalizers = append(finalizers, s)
+		}
+	}
+	return finalizers
+}
+
+func (m unstructuredMeta) SetName(name string)           { m.metadata()["name"] = name }
+func (m unstructuredMeta) SetNamespace(namespace string) { m.metadata()["namespace"] = namespace }
+func (m unstructuredMeta) SetUid(uid string)             { m.metadata()["uid"] = uid }
+func (m unstructuredMeta) SetResourceVersion(rv string)  { m.metadata()["resourceVersion"] = rv }
+
+func (m unstructuredMeta) setCreationTimestamp(t time.Time) {
+	m.metadata()["creationTimestamp"] = t.UTC().Format(time.RFC3339)
+}
+
+func (m unstructuredMeta) copyCreationTimestamp(from objectMeta) {
+	if f, ok := from.(unstructuredMeta); ok {
+		if ts, ok := f.metadata()["creationTimestamp"]; ok {
+			m.metadata()["creationTimestamp"] = ts
+		}
+	}
+}
+
+func (m unstructuredMeta) deleting() bool {
+	_, ok := m.metadata()["deletionTimestamp"]
+	return ok
+}
+
+func (m unstructuredMeta) setDeletionTimestamp(t time.Time) {
+	m.metadata()["deletionTimestamp"] = t.UTC().Format(time.RFC3339)
+}
+
+func (m unstructuredMeta) copyDeletionTimestamp(from objectMeta) {
+	if f, ok := from.(unstructuredMeta); ok {
+		if ts, ok := f.metadata()["deletionTimestamp"]; ok {
+			m.metadata()["deletionTimestamp"] = ts
+		} else {
+			delete(m.metadata(), "deletionTimestamp")
+		}
+	}
+}
+
+// clone returns a deep copy of an object.
+func clone(obj k8s.Resource) (k8s.Resource, error) {
+	if msg, ok := obj.(proto.Message); ok {
+		return proto.Clone(msg).(k8s.Resource), nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	c := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(k8s.Resource)
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// copyStatus sets the status of dst to the status of src.
+func copyStatus(dst, src k8s.Resource) {
+	if u, ok := dst.(*k8s.Unstructured); ok {
+		if status, ok := src.(*k8s.Unstructured).Object["status"]; ok {
+			u.Object["status"] = status
+		} else {
+			delete(u.Object, "status")
+		}
+		return
+	}
+	d := reflect.ValueOf(dst).Elem().FieldByName("Status")
+	if d.IsValid() {
+		d.Set(reflect.ValueOf(src).Elem().FieldByName("Status"))
+	}
+}
@@ -0,0 +1,215 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ericchiang/k8s"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+// requirement is a single term of a label or field selector.
+type requirement struct {
+	key    string
+	op     string // "=", "!=", "in", "notin", "exists" or "!exists"
+	values []string
+}
+
+var setRequirement = regexp.MustCompile(`^(\S+)\s+(in|notin)\s+\((.*)\)$`)
+
+// splitSelector splits a selector on commas which aren't part of a set, such as
+// "env in (prod,staging)".
+func splitSelector(s string) []string {
+	var (
+		terms []string
+		depth int
+		start int
+	)
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, s[start:])
+}
+
+// parseLabelSelector parses the label selector syntax produced by
+// k8s.LabelSelector.
+func parseLabelSelector(s string) ([]requirement, error) {
+	var reqs []requirement
+	for _, term := range splitSelector(s) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		if m := setRequirement.FindStringSubmatch(term); m != nil {
+			var values []string
+			for _, v := range strings.Split(m[3], ",") {
+				values = append(values, strings.TrimSpace(v))
+			}
+			reqs = append(reqs, requirement{m[1], m[2], values})
+			continue
+		}
+		req, err := parseEquality(term)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// parseFieldSelector parses a field selector, which only supports equality.
+func parseFieldSelector(s string) ([]requirement, error) {
+	var reqs []requirement
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		req, err := parseEquality(term)
+		if err != nil {
+			return nil, err
+		}
+		if req.op != "=" && req.op != "!=" {
+			return nil, fmt.Errorf("invalid field selector %q", term)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+func parseEquality(term string) (requirement, error) {
+	for _, op := range []string{"!=", "==", "="} {
+		if i := strings.Index(term, op); i >= 0 {
+			key := strings.TrimSpace(term[:i])
+			if key == "" {
+				return requirement{}, fmt.Errorf("invalid selector %q", term)
+			}
+			val := strings.TrimSpace(term[i+len(op):])
+			if op == "==" {
+				op = "="
+			}
+			return requirement{key, op, []string{val}}, nil
+		}
+	}
+	if strings.HasPrefix(term, "!") {
+		return requirement{strings.TrimSpace(term[1:]), "!exists", nil}, nil
+	}
+	return requirement{term, "exists", nil}, nil
+}
+
+func (r requirement) matches(val string, ok bool) bool {
+	contains := func() bool {
+		for _, v := range r.values {
+			if v == val {
+				return true
+			}
+		}
+		return false
+	}
+	switch r.op {
+	case "=":
+		return ok && val == r.values[0]
+	case "!=":
+		return !ok || val != r.values[0]
+	case "in":
+		return ok && contains()
+	case "notin":
+		return !ok || !contains()
+	case "exists":
+		return ok
+	case "!exists":
+		return !ok
+	}
+	return false
+}
+
+func matchLabels(reqs []requirement, labels map[string]string) bool {
+	for _, r := range reqs {
+		val, ok := labels[r.key]
+		if !r.matches(val, ok) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchFields evaluates a field selector against the JSON representation of an
+// object, such as "spec.nodeName=node-1".
+func matchFields(reqs []requirement, obj k8s.Resource) bool {
+	if len(reqs) == 0 {
+		return true
+	}
+	var fields map[string]interface{}
+	if data, err := json.Marshal(obj); err == nil {
+		json.Unmarshal(data, &fields)
+	}
+	for _, r := range reqs {
+		val, ok := fieldValue(fields, r.key)
+		if !r.matches(val, ok) {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldValue(obj map[string]interface{}, path string) (string, bool) {
+	var v interface{} = obj
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if v, ok = m[key]; !ok {
+			return "", false
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case nil, map[string]interface{}, []interface{}:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// selectorMatches evaluates a structured label selector, such as the selector
+// of a PodDisruptionBudget. A nil or empty selector matches nothing.
+func selectorMatches(sel *metav1.LabelSelector, labels map[string]string) bool {
+	if sel == nil || (len(sel.GetMatchLabels()) == 0 && len(sel.GetMatchExpressions()) == 0) {
+		return false
+	}
+	var reqs []requirement
+	for key, val := range sel.GetMatchLabels() {
+		reqs = append(reqs, requirement{key, "=", []string{val}})
+	}
+	for _, expr := range sel.GetMatchExpressions() {
+		r := requirement{key: expr.GetKey(), values: expr.GetValues()}
+		switch expr.GetOperator() {
+		case "In":
+			r.op = "in"
+		case "NotIn":
+			r.op = "notin"
+		case "Exists":
+			r.op = "exists"
+		case "DoesNotExist":
+			r.op = "!exists"
+		default:
+			return false
+		}
+		reqs = append(reqs, r)
+	}
+	return matchLabels(reqs, labels)
+}
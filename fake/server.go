@@ -0,0 +1,759 @@
+/*
+Package fake implements an in-memory Kubernetes API server for unit tests.
+
+The server is served over HTTP using the httptest package, so code under test
+uses a real *k8s.Client and exercises the same encoding, decoding and error
+handling as it would against a real cluster. Registered types are served as
+protobuf or JSON, matching the content type requested by the client, while
+other resources, such as custom resources accessed through k8s.Dynamic, are
+stored as JSON.
+
+	srv := fake.NewServer()
+	defer srv.Close()
+
+	client := srv.Client()
+	cm := &corev1.ConfigMap{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-configmap"),
+			Namespace: k8s.String("default"),
+		},
+	}
+	if err := client.Create(ctx, cm); err != nil {
+		// handle error
+	}
+
+The server implements create, get, list, update, patch, delete, delete
+collection and watch, along with the "status" subresource, pod evictions and
+dry runs.
+Resource versions are assigned from a single counter on every write, and
+updates with a stale resource version fail with a 409 Conflict. Lists and
+watches support label selectors and equality based field selectors.
+
+Watches without a resource version begin with an ADDED event for each matching
+object. Watches from a resource version replay the events after it, so watching
+from the version returned by a get or list doesn't miss changes. The server
+keeps the last 1000 events of each resource, and watches from older versions
+fail with a 410 Gone, like they do once the API server compacts its history.
+
+Discovery information is served for the types registered with the k8s package,
+so discovery based helpers such as UpdateStatus and RESTMapper work as well.
+
+Objects with finalizers are marked for deletion by setting their deletion
+timestamp, and are removed once an update removes their last finalizer. There's
+no garbage collector, so propagation policies have no effect.
+
+Evictions of pods covered by a PodDisruptionBudget are rejected with a 429 Too
+Many Requests unless the status of the budget allows a disruption, and each
+eviction decrements the allowed disruptions. Tests play the part of the
+disruption controller by updating the status of the budget.
+
+The server performs no validation or defaulting beyond checking names and
+namespaces. Each version of a resource is stored separately. Strategic merge
+patches are applied as JSON merge patches, and JSON patches aren't supported.
+*/
+package fake
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/internal/apipath"
+)
+
+// Server is an in-memory API server.
+type Server struct {
+	srv *httptest.Server
+
+	// Closed when the server shuts down, to end active watches.
+	done chan struct{}
+
+	mu              sync.Mutex
+	resourceVersion int64
+	objects         map[objectKey]k8s.Resource
+	history         map[k8s.GroupVersionResource]*history
+	historySize     int
+	watchers        map[*watcher]struct{}
+}
+
+type objectKey struct {
+	gvr       k8s.GroupVersionResource
+	namespace string
+	name      string
+}
+
+// NewServer starts a new server. The server must be closed by the caller.
+func NewServer() *Server {
+	s := &Server{
+		done:     make(chan struct{}),
+		objects:     map[objectKey]k8s.Resource{},
+		history:     map[k8s.GroupVersionResource]*history{},
+		historySize: historySize,
+		watchers:    map[*watcher]struct{}{},
+	}
+	s.srv = httptest.NewServer(s)
+	return s
+}
+
+// URL returns the base URL of the server.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Client returns a client for the server, using "default" as its namespace.
+func (s *Server) Client() *k8s.Client {
+	return &k8s.Client{
+		Endpoint:  s.srv.URL,
+		Namespace: "default",
+		Client:    s.srv.Client(),
+	}
+}
+
+// Close ends all watches and shuts down the server.
+func (s *Server) Close() {
+	close(s.done)
+	s.srv.Close()
+}
+
+// request is a parsed resource request path, such as
+// "/apis/apps/v1/namespaces/default/deployments/my-deployment/status".
+type request struct {
+	gvr         k8s.GroupVersionResource
+	namespace   string
+	name        string
+	subresource string
+}
+
+func parsePath(p string) (request, bool) {
+	path, ok := apipath.Parse(p)
+	if !ok {
+		return request{}, false
+	}
+	return request{
+		gvr:         k8s.GroupVersionResource{Group: path.Group, Version: path.Version, Resource: path.Resource},
+		namespace:   path.Namespace,
+		name:        path.Name,
+		subresource: path.Subresource,
+	}, true
+}
+
+// call holds the state of a single request.
+type call struct {
+	s *Server
+	w http.ResponseWriter
+	r *http.Request
+	request
+
+	// Registered kind of the resource, nil if the resource isn't registered.
+	mapping *k8s.RESTMapping
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" && s.serveDiscovery(w, r) {
+		return
+	}
+	req, ok := parsePath(r.URL.Path)
+	c := &call{s: s, w: w, r: r, request: req}
+	if !ok {
+		c.writeError(http.StatusNotFound, "NotFound", "the server could not find the requested resource")
+		return
+	}
+	c.mapping, _ = k8s.RESTMappingFor(req.gvr)
+	if c.mapping != nil {
+		switch {
+		case c.mapping.Namespaced && req.namespace == "" && (req.name != "" || r.Method == "POST" || r.Method == "DELETE"):
+			c.writeError(http.StatusNotFound, "NotFound", "the server could not find the requested resource")
+			return
+		case !c.mapping.Namespaced && req.namespace != "":
+			c.writeError(http.StatusNotFound, "NotFound", "the server could not find the requested resource")
+			return
+		}
+	}
+
+	if dryRun := r.URL.Query().Get("dryRun"); dryRun != "" && dryRun != "All" {
+		c.writeError(http.StatusBadRequest, "BadRequest", "invalid dry run value %q, only \"All\" is supported", dryRun)
+		return
+	}
+
+	switch {
+	case req.subresource == "eviction":
+		c.evict()
+	case req.subresource != "" && req.subresource != "status":
+		c.writeError(http.StatusNotFound, "NotFound", "subresource %q not supported", req.subresource)
+	case r.Method == "GET" && req.name == "" && isWatch(r):
+		c.watch()
+	case r.Method == "GET" && req.name == "":
+		c.list()
+	case r.Method == "GET":
+		c.get()
+	case r.Method == "POST" && req.name == "":
+		c.create()
+	case r.Method == "PUT" && req.name != "":
+		c.update()
+	case r.Method == "PATCH" && req.name != "":
+		c.patch()
+	case r.Method == "DELETE" && req.name != "":
+		c.delete()
+	case r.Method == "DELETE":
+		c.deleteCollection()
+	default:
+		c.writeError(http.StatusMethodNotAllowed, "MethodNotAllowed", "method %s not supported", r.Method)
+	}
+}
+
+func isWatch(r *http.Request) bool {
+	w := r.URL.Query().Get("watch")
+	return w == "true" || w == "1"
+}
+
+// dryRun reports if the request shouldn't persist any changes.
+func (c *call) dryRun() bool {
+	return c.r.URL.Query().Get("dryRun") == "All"
+}
+
+func (c *call) key() objectKey {
+	return objectKey{c.gvr, c.namespace, c.name}
+}
+
+func (c *call) newObject() k8s.Resource {
+	if c.mapping != nil {
+		if obj, ok := k8s.NewResource(c.mapping.Kind); ok {
+			return obj
+		}
+	}
+	return new(k8s.Unstructured)
+}
+
+func (c *call) write(code int, obj interface{}) {
+	contentType, data, err := encode(c.r, obj)
+	if err != nil {
+		http.Error(c.w, fmt.Sprintf("encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	c.w.Header().Set("Content-Type", contentType)
+	c.w.WriteHeader(code)
+	c.w.Write(data)
+}
+
+func newStatus(code int, reason, message string) *metav1.Status {
+	return &metav1.Status{
+		Status:  k8s.String("Failure"),
+		Message: k8s.String(message),
+		Reason:  k8s.String(reason),
+		Code:    k8s.Int32(int32(code)),
+	}
+}
+
+func (c *call) writeError(code int, reason, format string, v ...interface{}) {
+	c.write(code, newStatus(code, reason, fmt.Sprintf(format, v...)))
+}
+
+func (c *call) writeNotFound() {
+	c.writeError(http.StatusNotFound, "NotFound", "%s %q not found", c.gvr.Resource, c.name)
+}
+
+// readObject decodes the request body.
+func (c *call) readObject() (k8s.Resource, objectMeta, bool) {
+	body, err := ioutil.ReadAll(c.r.Body)
+	if err != nil {
+		c.writeError(http.StatusBadRequest, "BadRequest", "read body: %v", err)
+		return nil, nil, false
+	}
+	obj := c.newObject()
+	if err := decode(c.r, body, obj); err != nil {
+		c.writeError(http.StatusBadRequest, "BadRequest", "decode body: %v", err)
+		return nil, nil, false
+	}
+	meta, err := metaFor(obj)
+	if err != nil {
+		c.writeError(http.StatusBadRequest, "BadRequest", "%v", err)
+		return nil, nil, false
+	}
+	return obj, meta, true
+}
+
+// checkNamespace sets the namespace of an object from the request, rejecting
+// objects which specify a different one.
+func (c *call) checkNamespace(meta objectMeta) bool {
+	if ns := meta.GetNamespace(); ns != "" && ns != c.namespace {
+		c.writeError(http.StatusBadRequest, "BadRequest", "the namespace of the object (%s) does not match the namespace on the request (%s)", ns, c.namespace)
+		return false
+	}
+	if c.namespace != "" {
+		meta.SetNamespace(c.namespace)
+	}
+	return true
+}
+
+// nextResourceVersion increments the server's resource version. The caller
+// must hold s.mu.
+func (s *Server) nextResourceVersion() string {
+	s.resourceVersion++
+	return strconv.FormatInt(s.resourceVersion, 10)
+}
+
+func (c *call) get() {
+	c.s.mu.Lock()
+	obj, ok := c.s.objects[c.key()]
+	c.s.mu.Unlock()
+	if !ok {
+		c.writeNotFound()
+		return
+	}
+	c.write(http.StatusOK, obj)
+}
+
+func (c *call) create() {
+	obj, meta, ok := c.readObject()
+	if !ok || !c.checkNamespace(meta) {
+		return
+	}
+
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	if meta.GetName() == "" {
+		if meta.GetGenerateName() == "" {
+			c.writeError(http.StatusUnprocessableEntity, "Invalid", "name or generateName is required")
+			return
+		}
+		meta.SetName(meta.GetGenerateName() + randomSuffix())
+	}
+	c.name = meta.GetName()
+	if _, ok := c.s.objects[c.key()]; ok {
+		c.writeError(http.StatusConflict, "AlreadyExists", "%s %q already exists", c.gvr.Resource, c.name)
+		return
+	}
+	meta.SetUid(newUID())
+	meta.setCreationTimestamp(time.Now())
+	if c.dryRun() {
+		c.write(http.StatusCreated, obj)
+		return
+	}
+	meta.SetResourceVersion(c.s.nextResourceVersion())
+
+	c.s.objects[c.key()] = obj
+	c.s.notify(c.key(), k8s.EventAdded, obj)
+	c.write(http.StatusCreated, obj)
+}
+
+func (c *call) update() {
+	obj, meta, ok := c.readObject()
+	if !ok {
+		return
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	c.replace(obj, meta)
+}
+
+func (c *call) patch() {
+	body, err := ioutil.ReadAll(c.r.Body)
+	if err != nil {
+		c.writeError(http.StatusBadRequest, "BadRequest", "read body: %v", err)
+		return
+	}
+	switch mediaType(c.r.Header.Get("Content-Type")) {
+	case string(k8s.PatchMerge), string(k8s.PatchStrategicMerge):
+	default:
+		c.writeError(http.StatusUnsupportedMediaType, "UnsupportedMediaType", "unsupported patch type %q", c.r.Header.Get("Content-Type"))
+		return
+	}
+	var patch interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		c.writeError(http.StatusBadRequest, "BadRequest", "decode patch: %v", err)
+		return
+	}
+
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	old, ok := c.s.objects[c.key()]
+	if !ok {
+		c.writeNotFound()
+		return
+	}
+	var doc interface{}
+	data, err := json.Marshal(old)
+	if err == nil {
+		err = json.Unmarshal(data, &doc)
+	}
+	if err == nil {
+		data, err = json.Marshal(mergePatch(doc, patch))
+	}
+	obj := c.newObject()
+	if err == nil {
+		err = json.Unmarshal(data, obj)
+	}
+	if err != nil {
+		c.writeError(http.StatusUnprocessableEntity, "Invalid", "apply patch: %v", err)
+		return
+	}
+	meta, err := metaFor(obj)
+	if err != nil {
+		c.writeError(http.StatusUnprocessableEntity, "Invalid", "%v", err)
+		return
+	}
+	c.replace(obj, meta)
+}
+
+// mergePatch applies a JSON merge patch, as defined by RFC 7386.
+func mergePatch(doc, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	d, ok := doc.(map[string]interface{})
+	if !ok {
+		d = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(d, k)
+			continue
+		}
+		d[k] = mergePatch(d[k], v)
+	}
+	return d
+}
+
+// replace stores a new version of an existing object. For the status
+// subresource, only the status of the object is changed. The caller must hold
+// s.mu.
+func (c *call) replace(obj k8s.Resource, meta objectMeta) {
+	if name := meta.GetName(); name != c.name {
+		c.writeError(http.StatusBadRequest, "BadRequest", "the name of the object (%s) does not match the name on the URL (%s)", name, c.name)
+		return
+	}
+	if !c.checkNamespace(meta) {
+		return
+	}
+	old, ok := c.s.objects[c.key()]
+	if !ok {
+		c.writeNotFound()
+		return
+	}
+	oldMeta, _ := metaFor(old)
+	if rv := meta.GetResourceVersion(); rv != "" && rv != oldMeta.GetResourceVersion() {
+		c.writeError(http.StatusConflict, "Conflict", "Operation cannot be fulfilled on %s %q: the object has been modified; please apply your changes to the latest version and try again", c.gvr.Resource, c.name)
+		return
+	}
+
+	if c.subresource == "status" {
+		updated, err := clone(old)
+		if err != nil {
+			c.writeError(http.StatusInternalServerError, "InternalError", "copy object: %v", err)
+			return
+		}
+		copyStatus(updated, obj)
+		obj = updated
+		meta, _ = metaFor(obj)
+	}
+	meta.SetUid(oldMeta.GetUid())
+	meta.copyCreationTimestamp(oldMeta)
+	meta.copyDeletionTimestamp(oldMeta)
+	if c.dryRun() {
+		meta.SetResourceVersion(oldMeta.GetResourceVersion())
+		c.write(http.StatusOK, obj)
+		return
+	}
+	meta.SetResourceVersion(c.s.nextResourceVersion())
+
+	c.s.objects[c.key()] = obj
+	c.s.notify(c.key(), k8s.EventModified, obj)
+	if meta.deleting() && len(meta.GetFinalizers()) == 0 {
+		// The last finalizer of an object marked for deletion was removed.
+		c.remove(c.key(), obj)
+	}
+	c.write(http.StatusOK, obj)
+}
+
+// deleteOptions are the delete options understood by the server.
+type deleteOptions struct {
+	Preconditions struct {
+		UID             string `json:"uid"`
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"preconditions"`
+	DryRun []string `json:"dryRun"`
+}
+
+// dryRun reports if the delete shouldn't persist any changes, as requested by
+// either the URL or the delete options.
+func (o *deleteOptions) dryRun(c *call) bool {
+	return c.dryRun() || (len(o.DryRun) == 1 && o.DryRun[0] == "All")
+}
+
+func (c *call) readDeleteOptions() (*deleteOptions, bool) {
+	opts := new(deleteOptions)
+	if body, err := ioutil.ReadAll(c.r.Body); err == nil && len(body) > 0 {
+		if err := json.Unmarshal(body, opts); err != nil {
+			c.writeError(http.StatusBadRequest, "BadRequest", "decode delete options: %v", err)
+			return nil, false
+		}
+	}
+	return opts, true
+}
+
+// checkPreconditions rejects deletes of objects not matching the preconditions
+// of the request.
+func (c *call) checkPreconditions(opts *deleteOptions, meta objectMeta) bool {
+	if uid := opts.Preconditions.UID; uid != "" && uid != meta.GetUid() {
+		c.writeError(http.StatusConflict, "Conflict", "Precondition failed: UID in precondition: %s, UID in object meta: %s", uid, meta.GetUid())
+		return false
+	}
+	if rv := opts.Preconditions.ResourceVersion; rv != "" && rv != meta.GetResourceVersion() {
+		c.writeError(http.StatusConflict, "Conflict", "Precondition failed: ResourceVersion in precondition: %s, ResourceVersion in object meta: %s", rv, meta.GetResourceVersion())
+		return false
+	}
+	return true
+}
+
+func (c *call) delete() {
+	opts, ok := c.readDeleteOptions()
+	if !ok {
+		return
+	}
+
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	old, ok := c.s.objects[c.key()]
+	if !ok {
+		c.writeNotFound()
+		return
+	}
+	meta, _ := metaFor(old)
+	if !c.checkPreconditions(opts, meta) {
+		return
+	}
+	if len(meta.GetFinalizers()) > 0 {
+		if !opts.dryRun(c) {
+			old = c.deleteObject(c.key(), old)
+		}
+		c.write(http.StatusOK, old)
+		return
+	}
+	if !opts.dryRun(c) {
+		c.remove(c.key(), old)
+	}
+	c.write(http.StatusOK, &metav1.Status{Status: k8s.String("Success")})
+}
+
+// deleteCollection deletes the objects matching the selectors of the request,
+// and responds with the deleted objects, as done by recent API servers.
+// Objects are only deleted if all of them match the preconditions.
+func (c *call) deleteCollection() {
+	labels, fields, ok := c.selectors()
+	if !ok {
+		return
+	}
+	opts, ok := c.readDeleteOptions()
+	if !ok {
+		return
+	}
+
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	objs := c.s.matching(c.filter(labels, fields))
+	for _, obj := range objs {
+		meta, _ := metaFor(obj)
+		if !c.checkPreconditions(opts, meta) {
+			return
+		}
+	}
+	if opts.dryRun(c) {
+		if list, ok := c.newList(objs, strconv.FormatInt(c.s.resourceVersion, 10)); ok {
+			c.write(http.StatusOK, list)
+		}
+		return
+	}
+	var deleted []k8s.Resource
+	for _, obj := range objs {
+		meta, _ := metaFor(obj)
+		key := objectKey{c.gvr, meta.GetNamespace(), meta.GetName()}
+		if obj = c.deleteObject(key, obj); obj != nil {
+			deleted = append(deleted, obj)
+		}
+	}
+	if list, ok := c.newList(deleted, strconv.FormatInt(c.s.resourceVersion, 10)); ok {
+		c.write(http.StatusOK, list)
+	}
+}
+
+// deleteObject deletes a stored object, returning the deleted version. Objects
+// with finalizers are marked for deletion instead, and removed once their
+// finalizers have been removed. The caller must hold s.mu.
+func (c *call) deleteObject(key objectKey, obj k8s.Resource) k8s.Resource {
+	meta, _ := metaFor(obj)
+	if len(meta.GetFinalizers()) == 0 {
+		return c.remove(key, obj)
+	}
+	if meta.deleting() {
+		return obj
+	}
+	marked, err := clone(obj)
+	if err != nil {
+		return nil
+	}
+	meta, _ = metaFor(marked)
+	meta.setDeletionTimestamp(time.Now())
+	meta.SetResourceVersion(c.s.nextResourceVersion())
+	c.s.objects[key] = marked
+	c.s.notify(key, k8s.EventModified, marked)
+	return marked
+}
+
+// remove deletes a stored object, returning the deleted version. The caller
+// must hold s.mu.
+func (c *call) remove(key objectKey, obj k8s.Resource) k8s.Resource {
+	delete(c.s.objects, key)
+
+	// The deleted object is reported with a new resource version, as done by
+	// the API server.
+	deleted, err := clone(obj)
+	if err != nil {
+		return nil
+	}
+	meta, _ := metaFor(deleted)
+	meta.SetResourceVersion(c.s.nextResourceVersion())
+	c.s.notify(key, k8s.EventDeleted, deleted)
+	return deleted
+}
+
+// selectors parses the label and field selectors of a list or watch request.
+func (c *call) selectors() (labels, fields []requirement, ok bool) {
+	q := c.r.URL.Query()
+	labels, err := parseLabelSelector(q.Get("labelSelector"))
+	if err != nil {
+		c.writeError(http.StatusBadRequest, "BadRequest", "invalid label selector: %v", err)
+		return nil, nil, false
+	}
+	fields, err = parseFieldSelector(q.Get("fieldSelector"))
+	if err != nil {
+		c.writeError(http.StatusBadRequest, "BadRequest", "invalid field selector: %v", err)
+		return nil, nil, false
+	}
+	return labels, fields, true
+}
+
+// filter returns a function matching objects within the scope of a list or
+// watch request.
+func (c *call) filter(labels, fields []requirement) func(key objectKey, obj k8s.Resource) bool {
+	return func(key objectKey, obj k8s.Resource) bool {
+		if key.gvr != c.gvr || (c.namespace != "" && key.namespace != c.namespace) {
+			return false
+		}
+		meta, err := metaFor(obj)
+		if err != nil {
+			return false
+		}
+		return matchLabels(labels, meta.GetLabels()) && matchFields(fields, obj)
+	}
+}
+
+// matching returns the stored objects accepted by a filter, sorted by namespace
+// and name. The caller must hold s.mu.
+func (s *Server) matching(match func(objectKey, k8s.Resource) bool) []k8s.Resource {
+	var keys []objectKey
+	for key, obj := range s.objects {
+		if match(key, obj) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].namespace != keys[j].namespace {
+			return keys[i].namespace < keys[j].namespace
+		}
+		return keys[i].name < keys[j].name
+	})
+	objs := make([]k8s.Resource, len(keys))
+	for i, key := range keys {
+		objs[i] = s.objects[key]
+	}
+	return objs
+}
+
+func (c *call) list() {
+	labels, fields, ok := c.selectors()
+	if !ok {
+		return
+	}
+	c.s.mu.Lock()
+	objs := c.s.matching(c.filter(labels, fields))
+	rv := strconv.FormatInt(c.s.resourceVersion, 10)
+	c.s.mu.Unlock()
+
+	if list, ok := c.newList(objs, rv); ok {
+		c.write(http.StatusOK, list)
+	}
+}
+
+// newList returns a list of objects with the given resource version.
+func (c *call) newList(objs []k8s.Resource, rv string) (interface{}, bool) {
+	if c.mapping == nil {
+		list := &k8s.UnstructuredList{
+			Object: map[string]interface{}{
+				"apiVersion": apiVersion(c.gvr),
+				"kind":       "List",
+				"metadata":   map[string]interface{}{"resourceVersion": rv},
+			},
+		}
+		for _, obj := range objs {
+			u := obj.(*k8s.Unstructured)
+			list.Items = append(list.Items, u)
+			list.Object["kind"] = u.GetKind() + "List"
+		}
+		return list, true
+	}
+
+	gvk := c.mapping.Kind
+	gvk.Kind += "List"
+	list, ok := k8s.NewResourceList(gvk)
+	if !ok {
+		c.writeError(http.StatusInternalServerError, "InternalError", "no list type registered for %s", c.mapping.Kind)
+		return nil, false
+	}
+	v := reflect.ValueOf(list).Elem()
+	v.FieldByName("Metadata").Set(reflect.ValueOf(&metav1.ListMeta{ResourceVersion: &rv}))
+	items := v.FieldByName("Items")
+	for _, obj := range objs {
+		items.Set(reflect.Append(items, reflect.ValueOf(obj)))
+	}
+	return list, true
+}
+
+func apiVersion(gvr k8s.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return gvr.Version
+	}
+	return gvr.Group + "/" + gvr.Version
+}
+
+func newUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func randomSuffix() string {
+	const chars = "bcdfghjklmnpqrstvwxz2456789"
+	b := make([]byte, 5)
+	rand.Read(b)
+	for i := range b {
+		b[i] = chars[int(b[i])%len(chars)]
+	}
+	return string(b)
+}
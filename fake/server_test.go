@@ -0,0 +1,458 @@
+package fake
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+func newConfigMap(name string, labels map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String(name),
+			Namespace: k8s.String("default"),
+			Labels:    labels,
+		},
+		Data: map[string]string{"foo": "bar"},
+	}
+}
+
+func wantCode(t *testing.T, err error, code int) {
+	t.Helper()
+	apiErr, ok := err.(*k8s.APIError)
+	if !ok {
+		t.Fatalf("expected *k8s.APIError with code %d, got %v", code, err)
+	}
+	if apiErr.Code != code {
+		t.Fatalf("expected status code %d, got %d: %v", code, apiErr.Code, err)
+	}
+}
+
+func TestCRUD(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	cm := newConfigMap("my-configmap", nil)
+	if err := client.Create(ctx, cm); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if cm.Metadata.GetUid() == "" || cm.Metadata.GetResourceVersion() == "" {
+		t.Errorf("expected uid and resource version to be set: %v", cm.Metadata)
+	}
+	if cm.Metadata.GetCreationTimestamp() == nil {
+		t.Errorf("expected creation timestamp to be set")
+	}
+	wantCode(t, client.Create(ctx, newConfigMap("my-configmap", nil)), http.StatusConflict)
+
+	var got corev1.ConfigMap
+	if err := client.Get(ctx, "default", "my-configmap", &got); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Data["foo"] != "bar" {
+		t.Errorf("expected data to be stored, got %v", got.Data)
+	}
+
+	stale := newConfigMap("my-configmap", nil)
+	stale.Metadata.ResourceVersion = k8s.String("0")
+	wantCode(t, client.Update(ctx, stale), http.StatusConflict)
+
+	got.Data["foo"] = "baz"
+	rv := got.Metadata.GetResourceVersion()
+	if err := client.Update(ctx, &got); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got.Metadata.GetResourceVersion() == rv {
+		t.Errorf("expected resource version to change on update")
+	}
+	if got.Metadata.GetUid() != cm.Metadata.GetUid() {
+		t.Errorf("expected uid to be preserved on update")
+	}
+
+	if err := client.Delete(ctx, &got); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	wantCode(t, client.Get(ctx, "default", "my-configmap", &got), http.StatusNotFound)
+	wantCode(t, client.Delete(ctx, &got), http.StatusNotFound)
+}
+
+func TestList(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	for _, cm := range []*corev1.ConfigMap{
+		newConfigMap("a", map[string]string{"env": "prod"}),
+		newConfigMap("b", map[string]string{"env": "staging"}),
+		newConfigMap("c", nil),
+	} {
+		if err := client.Create(ctx, cm); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	other := newConfigMap("d", map[string]string{"env": "prod"})
+	other.Metadata.Namespace = k8s.String("other")
+	if err := client.Create(ctx, other); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	tests := []struct {
+		namespace string
+		options   []k8s.Option
+		want      []string
+	}{
+		{"default", nil, []string{"a", "b", "c"}},
+		{k8s.AllNamespaces, nil, []string{"a", "b", "c", "d"}},
+		{k8s.AllNamespaces, []k8s.Option{k8s.QueryParam("labelSelector", "env=prod")}, []string{"a", "d"}},
+		{"default", []k8s.Option{k8s.QueryParam("labelSelector", "env in (prod,staging)")}, []string{"a", "b"}},
+		{"default", []k8s.Option{k8s.QueryParam("labelSelector", "!env")}, []string{"c"}},
+		{"default", []k8s.Option{k8s.QueryParam("fieldSelector", "metadata.name=b")}, []string{"b"}},
+	}
+	for _, test := range tests {
+		var list corev1.ConfigMapList
+		if err := client.List(ctx, test.namespace, &list, test.options...); err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		var got []string
+		for _, cm := range list.Items {
+			got = append(got, cm.Metadata.GetName())
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("list %q %d options: got %q, want %q", test.namespace, len(test.options), got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("list %q %d options: got %q, want %q", test.namespace, len(test.options), got, test.want)
+				break
+			}
+		}
+		if list.Metadata.GetResourceVersion() == "" {
+			t.Errorf("expected list to have a resource version")
+		}
+	}
+}
+
+func TestDeleteCollection(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	for _, cm := range []*corev1.ConfigMap{
+		newConfigMap("a", map[string]string{"env": "prod"}),
+		newConfigMap("b", map[string]string{"env": "prod"}),
+		newConfigMap("c", map[string]string{"env": "staging"}),
+	} {
+		if err := client.Create(ctx, cm); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	var deleted corev1.ConfigMapList
+	status, err := client.DeleteCollection(ctx, "default", &deleted, k8s.QueryParam("labelSelector", "env=prod"))
+	if err != nil {
+		t.Fatalf("delete collection: %v", err)
+	}
+	if status.GetStatus() != "Success" {
+		t.Errorf("expected successful status, got %v", status)
+	}
+	if len(deleted.Items) != 2 {
+		t.Errorf("expected 2 deleted items, got %d", len(deleted.Items))
+	}
+
+	var list corev1.ConfigMapList
+	if err := client.List(ctx, "default", &list); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Metadata.GetName() != "c" {
+		t.Errorf("expected only configmap c to remain, got %v", list.Items)
+	}
+
+	_, err = client.DeleteCollection(ctx, k8s.AllNamespaces, new(corev1.ConfigMapList))
+	wantCode(t, err, http.StatusNotFound)
+}
+
+func TestDryRun(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	cm := newConfigMap("my-configmap", nil)
+	if err := client.Create(ctx, cm, k8s.DryRun()); err != nil {
+		t.Fatalf("dry run create: %v", err)
+	}
+	if cm.Metadata.GetUid() == "" {
+		t.Errorf("expected dry run create to return the would-be object")
+	}
+	var got corev1.ConfigMap
+	wantCode(t, client.Get(ctx, "default", "my-configmap", &got), http.StatusNotFound)
+
+	cm = newConfigMap("my-configmap", nil)
+	if err := client.Create(ctx, cm); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	rv := cm.Metadata.GetResourceVersion()
+
+	cm.Data["foo"] = "baz"
+	if err := client.Update(ctx, cm, k8s.DryRun()); err != nil {
+		t.Fatalf("dry run update: %v", err)
+	}
+	if cm.Data["foo"] != "baz" || cm.Metadata.GetResourceVersion() != rv {
+		t.Errorf("unexpected dry run update result: %v %v", cm.Data, cm.Metadata)
+	}
+	patch := []byte(`{"data":{"foo":"qux"}}`)
+	if err := client.Patch(ctx, cm, k8s.PatchMerge, patch, k8s.DryRun()); err != nil {
+		t.Fatalf("dry run patch: %v", err)
+	}
+	if cm.Data["foo"] != "qux" {
+		t.Errorf("unexpected dry run patch result: %v", cm.Data)
+	}
+	if err := client.Delete(ctx, cm, k8s.DryRun()); err != nil {
+		t.Fatalf("dry run delete: %v", err)
+	}
+	if _, err := client.DeleteCollection(ctx, "default", new(corev1.ConfigMapList), k8s.DryRun()); err != nil {
+		t.Fatalf("dry run delete collection: %v", err)
+	}
+
+	if err := client.Get(ctx, "default", "my-configmap", &got); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Data["foo"] != "bar" || got.Metadata.GetResourceVersion() != rv {
+		t.Errorf("dry runs modified the stored object: %v %v", got.Data, got.Metadata)
+	}
+
+	err := client.Create(ctx, newConfigMap("other", nil), k8s.QueryParam("dryRun", "Some"))
+	wantCode(t, err, http.StatusBadRequest)
+}
+
+func TestWatch(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	cm := newConfigMap("a", nil)
+	if err := client.Create(ctx, cm); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	w, err := client.Watch(ctx, "default", new(corev1.ConfigMap))
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer w.Close()
+
+	want := func(eventType, name string) {
+		t.Helper()
+		got := new(corev1.ConfigMap)
+		gotType, err := w.Next(got)
+		if err != nil {
+			t.Fatalf("next event: %v", err)
+		}
+		if gotType != eventType || got.Metadata.GetName() != name {
+			t.Fatalf("expected %s event for %s, got %s event for %s", eventType, name, gotType, got.Metadata.GetName())
+		}
+	}
+	want(k8s.EventAdded, "a")
+
+	cm.Data["foo"] = "baz"
+	if err := client.Update(ctx, cm); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	want(k8s.EventModified, "a")
+
+	other := newConfigMap("b", nil)
+	other.Metadata.Namespace = k8s.String("other")
+	if err := client.Create(ctx, other); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := client.Delete(ctx, cm); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	want(k8s.EventDeleted, "a")
+}
+
+func TestWatchResourceVersion(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.historySize = 2
+	client := srv.Client()
+	ctx := context.Background()
+
+	cm := newConfigMap("a", nil)
+	if err := client.Create(ctx, cm); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	created := cm.Metadata.GetResourceVersion()
+	cm.Data["foo"] = "baz"
+	if err := client.Update(ctx, cm); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	// Events after the resource version are replayed.
+	w, err := client.Watch(ctx, "default", new(corev1.ConfigMap), k8s.ResourceVersion(created))
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	got := new(corev1.ConfigMap)
+	eventType, err := w.Next(got)
+	w.Close()
+	if err != nil {
+		t.Fatalf("next event: %v", err)
+	}
+	if eventType != k8s.EventModified || got.Data["foo"] != "baz" {
+		t.Errorf("expected update to be replayed, got %s event %v", eventType, got)
+	}
+
+	// Once the first update is dropped from the history, watching from the
+	// resource version of the create fails.
+	cm.Data["foo"] = "qux"
+	if err := client.Update(ctx, cm); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := client.Update(ctx, cm); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	_, err = client.Watch(ctx, "default", new(corev1.ConfigMap), k8s.ResourceVersion(created))
+	if apiErr, ok := err.(*k8s.APIError); !ok || apiErr.Code != http.StatusGone || apiErr.Status.GetReason() != "Expired" {
+		t.Errorf("expected 410 Gone, got %v", err)
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	pod := &corev1.Pod{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-pod"),
+			Namespace: k8s.String("default"),
+		},
+		Spec: &corev1.PodSpec{NodeName: k8s.String("node-1")},
+	}
+	if err := client.Create(ctx, pod); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	pod.Spec.NodeName = k8s.String("node-2")
+	pod.Status = &corev1.PodStatus{Phase: k8s.String("Running")}
+	if err := client.UpdateStatus(ctx, pod); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if got := pod.GetStatus().GetPhase(); got != "Running" {
+		t.Errorf("expected status to be updated, got phase %q", got)
+	}
+	if got := pod.GetSpec().GetNodeName(); got != "node-1" {
+		t.Errorf("expected spec to be unchanged by status update, got node %q", got)
+	}
+}
+
+func TestDynamic(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := k8s.NewDynamicClient(srv.Client())
+	ctx := context.Background()
+	gvr := k8s.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	u := &k8s.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"spec":       map[string]interface{}{"size": "large"},
+	}}
+	u.SetName("my-widget")
+	u.SetNamespace("default")
+	if err := client.Create(ctx, gvr, u); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if u.GetUID() == "" {
+		t.Errorf("expected uid to be set")
+	}
+
+	patch := []byte(`{"metadata":{"labels":{"color":"blue"}},"spec":{"size":null}}`)
+	if err := client.Patch(ctx, gvr, u, k8s.PatchMerge, patch); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if u.GetLabels()["color"] != "blue" {
+		t.Errorf("expected label to be added by patch, got %v", u.GetLabels())
+	}
+	if _, ok := u.Object["spec"].(map[string]interface{})["size"]; ok {
+		t.Errorf("expected field to be removed by patch")
+	}
+
+	var list k8s.UnstructuredList
+	if err := client.List(ctx, gvr, "default", &list, k8s.QueryParam("labelSelector", "color=blue")); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].GetName() != "my-widget" {
+		t.Errorf("expected list to contain my-widget, got %d items", len(list.Items))
+	}
+
+	if err := client.Delete(ctx, gvr, u); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	wantCode(t, client.Get(ctx, gvr, "default", "my-widget", new(k8s.Unstructured)), http.StatusNotFound)
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want request
+	}{
+		{"/api/v1/pods", request{gvr: k8s.GroupVersionResource{Version: "v1", Resource: "pods"}}},
+		{"/api/v1/namespaces", request{gvr: k8s.GroupVersionResource{Version: "v1", Resource: "namespaces"}}},
+		{"/api/v1/namespaces/foo", request{gvr: k8s.GroupVersionResource{Version: "v1", Resource: "namespaces"}, name: "foo"}},
+		{"/api/v1/namespaces/foo/status", request{gvr: k8s.GroupVersionResource{Version: "v1", Resource: "namespaces"}, name: "foo", subresource: "status"}},
+		{"/api/v1/namespaces/foo/pods", request{gvr: k8s.GroupVersionResource{Version: "v1", Resource: "pods"}, namespace: "foo"}},
+		{
+			"/apis/apps/v1/namespaces/foo/deployments/bar/scale",
+			request{gvr: k8s.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, namespace: "foo", name: "bar", subresource: "scale"},
+		},
+	}
+	for _, test := range tests {
+		got, ok := parsePath(test.path)
+		if !ok {
+			t.Errorf("parsePath(%q) failed", test.path)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("parsePath(%q): got %+v, want %+v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{"env": "prod", "tier": "frontend"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env==prod", true},
+		{"env!=prod", false},
+		{"env=prod,tier=backend", false},
+		{"env in (prod, staging)", true},
+		{"env notin (prod,staging),tier", false},
+		{"tier,!team", true},
+		{"team", false},
+	}
+	for _, test := range tests {
+		reqs, err := parseLabelSelector(test.selector)
+		if err != nil {
+			t.Errorf("parse %q: %v", test.selector, err)
+			continue
+		}
+		if got := matchLabels(reqs, labels); got != test.want {
+			t.Errorf("selector %q: got %t, want %t", test.selector, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package fake
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ericchiang/k8s"
+)
+
+// Number of events buffered for each watch. Watches which fall further behind
+// are closed, like the API server does for slow clients.
+const watchBuffer = 100
+
+// Number of events kept for each resource, so watches can start from an earlier
+// resource version. Watches starting before the oldest kept event fail with a
+// 410 Gone, like they do once the API server has compacted its history.
+const historySize = 1000
+
+type watchEvent struct {
+	eventType string
+	obj       k8s.Resource
+}
+
+// history is the log of recent events of a resource.
+type history struct {
+	// Resource version of the last event dropped from the log.
+	compacted int64
+	events    []loggedEvent
+}
+
+type loggedEvent struct {
+	resourceVersion int64
+	key             objectKey
+	watchEvent
+}
+
+// since returns the logged events after a resource version which are accepted
+// by a filter. It reports false if events after the resource version have
+// already been dropped from the log.
+func (h *history) since(resourceVersion int64, match func(objectKey, k8s.Resource) bool) ([]watchEvent, bool) {
+	if h == nil {
+		return nil, true
+	}
+	if resourceVersion < h.compacted {
+		return nil, false
+	}
+	var events []watchEvent
+	for _, e := range h.events {
+		if e.resourceVersion > resourceVersion && match(e.key, e.obj) {
+			events = append(events, e.watchEvent)
+		}
+	}
+	return events, true
+}
+
+type watcher struct {
+	match  func(objectKey, k8s.Resource) bool
+	events chan watchEvent
+}
+
+// notify records an event in the history of the object's resource, and sends it
+// to all watches matching the object. The event is logged with the server's
+// current resource version, which every write sets on the object before
+// notifying. The caller must hold s.mu.
+func (s *Server) notify(key objectKey, eventType string, obj k8s.Resource) {
+	h, ok := s.history[key.gvr]
+	if !ok {
+		h = new(history)
+		s.history[key.gvr] = h
+	}
+	h.events = append(h.events, loggedEvent{s.resourceVersion, key, watchEvent{eventType, obj}})
+	if len(h.events) > s.historySize {
+		h.compacted = h.events[0].resourceVersion
+		h.events = h.events[1:]
+	}
+
+	for w := range s.watchers {
+		if !w.match(key, obj) {
+			continue
+		}
+		select {
+		case w.events <- watchEvent{eventType, obj}:
+		default:
+			delete(s.watchers, w)
+			close(w.events)
+		}
+	}
+}
+
+func (c *call) watch() {
+	labels, fields, ok := c.selectors()
+	if !ok {
+		return
+	}
+	q := c.r.URL.Query()
+	var timeout <-chan time.Time
+	if s := q.Get("timeoutSeconds"); s != "" {
+		seconds, err := strconv.Atoi(s)
+		if err != nil {
+			c.writeError(http.StatusBadRequest, "BadRequest", "invalid timeoutSeconds %q", s)
+			return
+		}
+		timeout = time.After(time.Duration(seconds) * time.Second)
+	}
+
+	w := &watcher{
+		match:  c.filter(labels, fields),
+		events: make(chan watchEvent, watchBuffer),
+	}
+
+	c.s.mu.Lock()
+	var initial []watchEvent
+	switch rv := q.Get("resourceVersion"); rv {
+	case "", "0":
+		// Without a resource version, a watch begins with the current state of
+		// every matching object.
+		for _, obj := range c.s.matching(w.match) {
+			initial = append(initial, watchEvent{k8s.EventAdded, obj})
+		}
+	default:
+		// Otherwise it begins with the events after the resource version.
+		since, err := strconv.ParseInt(rv, 10, 64)
+		if err != nil {
+			c.s.mu.Unlock()
+			c.writeError(http.StatusBadRequest, "BadRequest", "invalid resourceVersion %q", rv)
+			return
+		}
+		h := c.s.history[c.gvr]
+		events, ok := h.since(since, w.match)
+		if !ok {
+			c.s.mu.Unlock()
+			c.writeError(http.StatusGone, "Expired", "too old resource version: %d (%d)", since, h.compacted)
+			return
+		}
+		initial = events
+	}
+	c.s.watchers[w] = struct{}{}
+	c.s.mu.Unlock()
+
+	defer func() {
+		c.s.mu.Lock()
+		if _, ok := c.s.watchers[w]; ok {
+			delete(c.s.watchers, w)
+			close(w.events)
+		}
+		c.s.mu.Unlock()
+	}()
+
+	pb := acceptsPB(c.r)
+	if pb {
+		c.w.Header().Set("Content-Type", contentTypePB+";stream=watch")
+	} else {
+		c.w.Header().Set("Content-Type", contentTypeJSON)
+	}
+	c.w.WriteHeader(http.StatusOK)
+	flusher, _ := c.w.(http.Flusher)
+
+	send := func(eventType string, obj k8s.Resource) bool {
+		data, err := encodeEvent(pb, eventType, obj)
+		if err != nil {
+			return false
+		}
+		if _, err := c.w.Write(data); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+
+	for _, e := range initial {
+		if !send(e.eventType, e.obj) {
+			return
+		}
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case e, ok := <-w.events:
+			if !ok || !send(e.eventType, e.obj) {
+				return
+			}
+		case <-timeout:
+			return
+		case <-c.r.Context().Done():
+			return
+		case <-c.s.done:
+			return
+		}
+	}
+}
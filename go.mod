@@ -1,7 +1,9 @@
 module github.com/ericchiang/k8s
 
 require (
+	github.com/ghodss/yaml v1.0.0
 	github.com/golang/protobuf v1.2.0
 	golang.org/x/net v0.0.0-20190125091013-d26f9f9a57f3
 	golang.org/x/text v0.3.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,185 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ericchiang/k8s/internal/apipath"
+)
+
+// RequestInfo describes a completed request to the API server.
+type RequestInfo struct {
+	// Context of the request.
+	Context context.Context
+
+	// Method is the HTTP method of the request, such as "GET".
+	Method string
+
+	// Verb is the Kubernetes verb of the request: "get", "list", "watch",
+	// "create", "update", "patch", "delete" or "deletecollection". Requests
+	// which don't address a resource, such as discovery requests, use the
+	// lowercased HTTP method.
+	Verb string
+
+	// Resource being accessed, empty for requests which don't address a
+	// resource. Group is empty for the legacy core group.
+	Group       string
+	Version     string
+	Resource    string
+	Subresource string
+	Namespace   string
+	Name        string
+
+	// Path of the request URL, without the query.
+	Path string
+
+	// StatusCode of the response, or 0 if no response was received.
+	StatusCode int
+
+	// Err is set if the request failed before a response was received.
+	Err error
+
+	// Duration of the request, from sending it to the response body being
+	// closed. For watches, this is the lifetime of the watch.
+	Duration time.Duration
+
+	// RequestBytes and ResponseBytes are the sizes of the request and response
+	// bodies.
+	RequestBytes  int64
+	ResponseBytes int64
+}
+
+// RequestHook is invoked after each request made by a Client completes. Hooks
+// are called synchronously, and must be safe for concurrent use.
+type RequestHook interface {
+	OnRequest(info *RequestInfo)
+}
+
+// RequestHookFunc adapts a function to a RequestHook.
+//
+//	client.Hooks = append(client.Hooks, k8s.RequestHookFunc(func(info *k8s.RequestInfo) {
+//		log.Printf("%s %s %d %s", info.Verb, info.Resource, info.StatusCode, info.Duration)
+//	}))
+type RequestHookFunc func(info *RequestInfo)
+
+// OnRequest calls f(info).
+func (f RequestHookFunc) OnRequest(info *RequestInfo) { f(info) }
+
+// send performs a request, invoking the client's hooks and ending its trace
+// span once the response body is closed.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if len(c.Hooks) == 0 && c.Tracer == nil {
+		if sc, ok := SpanContextFromContext(req.Context()); ok && req.Header.Get("traceparent") == "" {
+			req.Header.Set("traceparent", sc.Traceparent())
+		}
+		return c.client().Do(req)
+	}
+
+	start := time.Now()
+	info := newRequestInfo(req)
+	req, span := c.startSpan(req, info)
+	done := func(info *RequestInfo) {
+		if span != nil {
+			endSpan(span, info)
+		}
+		c.runHooks(info)
+	}
+
+	resp, err := c.client().Do(req)
+	if err != nil {
+		info.Err = err
+		info.Duration = time.Since(start)
+		done(info)
+		return nil, err
+	}
+	info.StatusCode = resp.StatusCode
+	resp.Body = &instrumentedBody{
+		body:  resp.Body,
+		start: start,
+		info:  info,
+		done:  done,
+	}
+	return resp, nil
+}
+
+func (c *Client) runHooks(info *RequestInfo) {
+	for _, hook := range c.Hooks {
+		hook.OnRequest(info)
+	}
+}
+
+// instrumentedBody counts the bytes read from a response body, and completes
+// the request's info when closed. A watcher may be closed while another
+// goroutine is reading from it, so the count is accessed atomically.
+type instrumentedBody struct {
+	body  io.ReadCloser
+	start time.Time
+	info  *RequestInfo
+	done  func(info *RequestInfo)
+
+	once sync.Once
+	n    int64
+}
+
+func (b *instrumentedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	atomic.AddInt64(&b.n, int64(n))
+	return n, err
+}
+
+func (b *instrumentedBody) Close() error {
+	err := b.body.Close()
+	b.once.Do(func() {
+		b.info.Duration = time.Since(b.start)
+		b.info.ResponseBytes = atomic.LoadInt64(&b.n)
+		b.done(b.info)
+	})
+	return err
+}
+
+func newRequestInfo(req *http.Request) *RequestInfo {
+	info := &RequestInfo{
+		Context: req.Context(),
+		Method:  req.Method,
+		Path:    req.URL.Path,
+	}
+	if req.ContentLength > 0 {
+		info.RequestBytes = req.ContentLength
+	}
+	// Paths which don't address a resource, such as "/apis/apps/v1", leave
+	// the resource fields empty.
+	if p, ok := apipath.Parse(req.URL.Path); ok {
+		info.Group, info.Version, info.Namespace = p.Group, p.Version, p.Namespace
+		info.Resource, info.Name, info.Subresource = p.Resource, p.Name, p.Subresource
+	}
+
+	switch {
+	case info.Resource == "":
+		info.Verb = strings.ToLower(req.Method)
+	case req.Method == "GET" && info.Name == "":
+		info.Verb = "list"
+		if w := req.URL.Query().Get("watch"); w == "true" || w == "1" {
+			info.Verb = "watch"
+		}
+	case req.Method == "GET":
+		info.Verb = "get"
+	case req.Method == "POST":
+		info.Verb = "create"
+	case req.Method == "PUT":
+		info.Verb = "update"
+	case req.Method == "PATCH":
+		info.Verb = "patch"
+	case req.Method == "DELETE" && info.Name == "":
+		info.Verb = "deletecollection"
+	case req.Method == "DELETE":
+		info.Verb = "delete"
+	default:
+		info.Verb = strings.ToLower(req.Method)
+	}
+	return info
+}
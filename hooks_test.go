@@ -0,0 +1,154 @@
+package k8s
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewRequestInfo(t *testing.T) {
+	tests := []struct {
+		method string
+		url    string
+		want   RequestInfo
+	}{
+		{
+			method: "GET",
+			url:    "/api/v1/namespaces/default/pods/my-pod",
+			want:   RequestInfo{Verb: "get", Version: "v1", Resource: "pods", Namespace: "default", Name: "my-pod"},
+		},
+		{
+			method: "GET",
+			url:    "/apis/apps/v1/deployments",
+			want:   RequestInfo{Verb: "list", Group: "apps", Version: "v1", Resource: "deployments"},
+		},
+		{
+			method: "GET",
+			url:    "/apis/apps/v1/namespaces/default/deployments?watch=true",
+			want:   RequestInfo{Verb: "watch", Group: "apps", Version: "v1", Resource: "deployments", Namespace: "default"},
+		},
+		{
+			method: "POST",
+			url:    "/api/v1/namespaces",
+			want:   RequestInfo{Verb: "create", Version: "v1", Resource: "namespaces"},
+		},
+		{
+			method: "PUT",
+			url:    "/api/v1/namespaces/my-namespace/status",
+			want:   RequestInfo{Verb: "update", Version: "v1", Resource: "namespaces", Name: "my-namespace", Subresource: "status"},
+		},
+		{
+			method: "PATCH",
+			url:    "/apis/apps/v1/namespaces/default/deployments/my-deployment/scale",
+			want:   RequestInfo{Verb: "patch", Group: "apps", Version: "v1", Resource: "deployments", Namespace: "default", Name: "my-deployment", Subresource: "scale"},
+		},
+		{
+			method: "DELETE",
+			url:    "/api/v1/namespaces/default/configmaps",
+			want:   RequestInfo{Verb: "deletecollection", Version: "v1", Resource: "configmaps", Namespace: "default"},
+		},
+		{
+			method: "GET",
+			url:    "/apis/apps/v1",
+			want:   RequestInfo{Verb: "get"},
+		},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.url, nil)
+		got := newRequestInfo(req)
+		got.Context, got.Method, got.Path = nil, "", ""
+		if *got != test.want {
+			t.Errorf("%s %s: got %+v, want %+v", test.method, test.url, *got, test.want)
+		}
+	}
+}
+
+func TestHooks(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"metadata":{"name":"my-resource"}}`))
+	}))
+	defer s.Close()
+
+	var infos []*RequestInfo
+	c := &Client{
+		Endpoint: s.URL,
+		Client:   s.Client(),
+		Hooks: []RequestHook{RequestHookFunc(func(info *RequestInfo) {
+			infos = append(infos, info)
+		})},
+	}
+
+	if err := c.Get(context.Background(), "default", "my-resource", new(CustomResource)); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("expected 1 hook call, got %d", len(infos))
+	}
+	info := infos[0]
+	if info.Verb != "get" || info.Group != "example.com" || info.Resource != "customs" || info.Name != "my-resource" {
+		t.Errorf("unexpected request info %+v", info)
+	}
+	if info.StatusCode != http.StatusOK {
+		t.Errorf("expected status code 200, got %d", info.StatusCode)
+	}
+	if info.ResponseBytes != int64(len(`{"metadata":{"name":"my-resource"}}`)) {
+		t.Errorf("unexpected response bytes %d", info.ResponseBytes)
+	}
+}
+
+func TestHooksWatchClose(t *testing.T) {
+	stop := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		for {
+			w.Write([]byte(`{"type":"ADDED","object":{"metadata":{"name":"my-resource"}}}` + "\n"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}))
+	defer s.Close()
+	defer close(stop)
+
+	infos := make(chan *RequestInfo, 1)
+	c := &Client{
+		Endpoint: s.URL,
+		Client:   s.Client(),
+		Hooks: []RequestHook{RequestHookFunc(func(info *RequestInfo) {
+			infos <- info
+		})},
+	}
+
+	w, err := c.Watch(context.Background(), "default", new(CustomResource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		for {
+			if _, err := w.Next(new(CustomResource)); err != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	w.Close()
+	<-read
+
+	info := <-infos
+	if info.Verb != "watch" {
+		t.Errorf("expected verb watch, got %q", info.Verb)
+	}
+	if info.ResponseBytes == 0 {
+		t.Errorf("expected response bytes to be counted")
+	}
+}
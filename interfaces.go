@@ -0,0 +1,60 @@
+package k8s
+
+import (
+	"context"
+
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+// Reader reads resources. It's implemented by *Client, and can be used by code
+// which only needs read access, allowing a fake or caching implementation to be
+// substituted.
+type Reader interface {
+	Get(ctx context.Context, namespace, name string, resp Resource, options ...Option) error
+	List(ctx context.Context, namespace string, resp ResourceList, options ...Option) error
+}
+
+// Writer creates, modifies and deletes resources. It's implemented by *Client.
+type Writer interface {
+	Create(ctx context.Context, req Resource, options ...Option) error
+	Update(ctx context.Context, req Resource, options ...Option) error
+	Patch(ctx context.Context, r Resource, pt PatchType, data []byte, options ...Option) error
+	Delete(ctx context.Context, req Resource, options ...Option) error
+	DeleteCollection(ctx context.Context, namespace string, list ResourceList, options ...Option) (*metav1.Status, error)
+}
+
+// StatusWriter modifies the "status" subresource of resources. It's implemented
+// by *Client.
+type StatusWriter interface {
+	UpdateStatus(ctx context.Context, r Resource, options ...Option) error
+	PatchStatus(ctx context.Context, r Resource, pt PatchType, data []byte, options ...Option) error
+}
+
+// WatcherFactory creates watches on resources. It's implemented by *Client.
+type WatcherFactory interface {
+	Watch(ctx context.Context, namespace string, r Resource, options ...Option) (*Watcher, error)
+}
+
+// Interface combines all operations on registered resource types. It's
+// implemented by *Client.
+//
+// Decorators can embed an Interface and override individual methods. For
+// example, to log every write:
+//
+//		type loggingClient struct {
+//			k8s.Interface
+//		}
+//
+//		func (c loggingClient) Create(ctx context.Context, r k8s.Resource, options ...k8s.Option) error {
+//			log.Printf("creating %s", r.GetMetadata().GetName())
+//			return c.Interface.Create(ctx, r, options...)
+//		}
+//
+type Interface interface {
+	Reader
+	Writer
+	StatusWriter
+	WatcherFactory
+}
+
+var _ Interface = (*Client)(nil)
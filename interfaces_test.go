@@ -0,0 +1,98 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+// countingClient counts the writes and watches made through an Interface.
+type countingClient struct {
+	k8s.Interface
+	creates, updates, deletes, watches int
+}
+
+func (c *countingClient) Create(ctx context.Context, r k8s.Resource, options ...k8s.Option) error {
+	c.creates++
+	return c.Interface.Create(ctx, r, options...)
+}
+
+func (c *countingClient) Update(ctx context.Context, r k8s.Resource, options ...k8s.Option) error {
+	c.updates++
+	return c.Interface.Update(ctx, r, options...)
+}
+
+func (c *countingClient) Delete(ctx context.Context, r k8s.Resource, options ...k8s.Option) error {
+	c.deletes++
+	return c.Interface.Delete(ctx, r, options...)
+}
+
+func (c *countingClient) Watch(ctx context.Context, namespace string, r k8s.Resource, options ...k8s.Option) (*k8s.Watcher, error) {
+	c.watches++
+	return c.Interface.Watch(ctx, namespace, r, options...)
+}
+
+// createOrUpdate is written against the narrow interfaces, like helpers which
+// accept any client.
+func createOrUpdate(ctx context.Context, r k8s.Reader, w k8s.Writer, secret *corev1.Secret) error {
+	meta := secret.GetMetadata()
+	var existing corev1.Secret
+	err := r.Get(ctx, meta.GetNamespace(), meta.GetName(), &existing)
+	if err != nil {
+		return w.Create(ctx, secret)
+	}
+	meta.ResourceVersion = existing.GetMetadata().ResourceVersion
+	return w.Update(ctx, secret)
+}
+
+func TestInterfaceDecorator(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := &countingClient{Interface: srv.Client()}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		secret := newSecret("my-secret")
+		secret.StringData = map[string]string{"attempt": string('0' + rune(i))}
+		if err := createOrUpdate(ctx, client, client, secret); err != nil {
+			t.Fatalf("create or update: %v", err)
+		}
+	}
+	if client.creates != 1 || client.updates != 1 {
+		t.Errorf("expected 1 create and 1 update, got %d and %d", client.creates, client.updates)
+	}
+
+	var secrets corev1.SecretList
+	if err := client.List(ctx, "default", &secrets); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(secrets.Items) != 1 {
+		t.Errorf("expected 1 secret, got %d", len(secrets.Items))
+	}
+
+	var w k8s.Writer = client
+	if _, err := w.DeleteCollection(ctx, "default", new(corev1.SecretList)); err != nil {
+		t.Fatalf("delete collection: %v", err)
+	}
+	if err := client.List(ctx, "default", &secrets); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(secrets.Items) != 0 {
+		t.Errorf("expected secrets to be deleted, got %d", len(secrets.Items))
+	}
+
+	secret := newSecret("my-secret")
+	if err := client.Create(ctx, secret); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := k8s.DeleteAndWait(ctx, client, secret, 10*time.Second); err != nil {
+		t.Fatalf("delete and wait: %v", err)
+	}
+	if client.deletes != 1 || client.watches != 1 {
+		t.Errorf("expected 1 delete and 1 watch through the decorator, got %d and %d", client.deletes, client.watches)
+	}
+}
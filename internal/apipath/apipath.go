@@ -0,0 +1,53 @@
+// Package apipath parses the URL paths of Kubernetes API requests.
+package apipath
+
+import "strings"
+
+// Path identifies the resource addressed by a request path, such as
+// "/apis/apps/v1/namespaces/default/deployments/my-deployment/scale". The core
+// API group is represented by an empty Group.
+type Path struct {
+	Group       string
+	Version     string
+	Namespace   string
+	Resource    string
+	Name        string
+	Subresource string
+}
+
+// Parse parses a resource request path, reporting false for paths which don't
+// address a resource, such as "/apis/apps/v1" or "/version".
+func Parse(p string) (Path, bool) {
+	var path Path
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		path.Version, parts = parts[1], parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		path.Group, path.Version, parts = parts[1], parts[2], parts[3:]
+	default:
+		return path, false
+	}
+
+	// "/api/v1/namespaces/{name}/status" is a subresource of a namespace, not
+	// a resource within it.
+	if len(parts) >= 3 && parts[0] == "namespaces" && !(len(parts) == 3 && (parts[2] == "status" || parts[2] == "finalize")) {
+		path.Namespace, parts = parts[1], parts[2:]
+	}
+	switch len(parts) {
+	case 3:
+		path.Subresource = parts[2]
+		fallthrough
+	case 2:
+		path.Name = parts[1]
+		fallthrough
+	case 1:
+		path.Resource = parts[0]
+	default:
+		return path, false
+	}
+	if path.Resource == "" {
+		return path, false
+	}
+	return path, true
+}
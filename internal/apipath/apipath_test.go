@@ -0,0 +1,41 @@
+package apipath
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		path string
+		want Path
+		ok   bool
+	}{
+		{"/api/v1/pods", Path{Version: "v1", Resource: "pods"}, true},
+		{"/api/v1/namespaces", Path{Version: "v1", Resource: "namespaces"}, true},
+		{"/api/v1/namespaces/foo", Path{Version: "v1", Resource: "namespaces", Name: "foo"}, true},
+		{"/api/v1/namespaces/foo/status", Path{Version: "v1", Resource: "namespaces", Name: "foo", Subresource: "status"}, true},
+		{"/api/v1/namespaces/foo/finalize", Path{Version: "v1", Resource: "namespaces", Name: "foo", Subresource: "finalize"}, true},
+		{"/api/v1/namespaces/foo/pods", Path{Version: "v1", Namespace: "foo", Resource: "pods"}, true},
+		{"/api/v1/namespaces/foo/pods/bar/eviction", Path{Version: "v1", Namespace: "foo", Resource: "pods", Name: "bar", Subresource: "eviction"}, true},
+		{"/api/v1/nodes/foo/status", Path{Version: "v1", Resource: "nodes", Name: "foo", Subresource: "status"}, true},
+		{
+			"/apis/apps/v1/namespaces/foo/deployments/bar/scale",
+			Path{Group: "apps", Version: "v1", Namespace: "foo", Resource: "deployments", Name: "bar", Subresource: "scale"},
+			true,
+		},
+		{"/apis/rbac.authorization.k8s.io/v1/clusterroles/", Path{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, true},
+		{"/api", Path{}, false},
+		{"/api/v1", Path{}, false},
+		{"/apis/apps/v1", Path{}, false},
+		{"/version", Path{}, false},
+		{"/api/v1/namespaces/foo/pods/bar/log/extra", Path{}, false},
+	}
+	for _, test := range tests {
+		got, ok := Parse(test.path)
+		if ok != test.ok {
+			t.Errorf("Parse(%q): wanted ok=%t, got %t", test.path, test.ok, ok)
+			continue
+		}
+		if ok && got != test.want {
+			t.Errorf("Parse(%q): got %+v, want %+v", test.path, got, test.want)
+		}
+	}
+}
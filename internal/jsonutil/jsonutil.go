@@ -0,0 +1,48 @@
+// Package jsonutil implements JSON encoding of generated types whose fields
+// are inlined by the API server, such as the Handler of a Probe.
+package jsonutil
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// MarshalInline encodes v with the fields of inline merged into it. The field of
+// v holding inline must be cleared, so it's omitted.
+func MarshalInline(v, inline interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.ValueOf(inline).IsNil() {
+		return data, nil
+	}
+	inlineData, err := json.Marshal(inline)
+	if err != nil {
+		return nil, err
+	}
+	var fields, inlineFields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(inlineData, &inlineFields); err != nil {
+		return nil, err
+	}
+	for k, v := range inlineFields {
+		fields[k] = v
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalInline decodes data into v and inline, which must both be pointers
+// to structs. It reports if any fields of inline were set.
+func UnmarshalInline(data []byte, v, inline interface{}) (bool, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, inline); err != nil {
+		return false, err
+	}
+	elem := reflect.ValueOf(inline).Elem()
+	return !reflect.DeepEqual(elem.Interface(), reflect.Zero(elem.Type()).Interface()), nil
+}
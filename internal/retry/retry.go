@@ -0,0 +1,57 @@
+// Package retry implements the policy used to reestablish watches which end
+// before the caller is done with them.
+package retry
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Bounds of the delay before reestablishing a watch which ended, doubling for
+// each consecutive retry.
+const (
+	minInterval = 100 * time.Millisecond
+	maxInterval = 5 * time.Second
+)
+
+// Backoff is the delay before reestablishing a watch. The zero value is ready
+// to use.
+type Backoff struct {
+	delay time.Duration
+}
+
+// Wait sleeps for the current delay, then doubles it. It returns ctx's error if
+// ctx is done first.
+func (b *Backoff) Wait(ctx context.Context) error {
+	if b.delay == 0 {
+		b.delay = minInterval
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(b.delay):
+	}
+	if b.delay *= 2; b.delay > maxInterval {
+		b.delay = maxInterval
+	}
+	return nil
+}
+
+// Reset returns the delay to its minimum, for example after a watch delivered
+// an event, so only consecutive failures back off.
+func (b *Backoff) Reset() {
+	b.delay = 0
+}
+
+// Retryable reports if a watch which failed with an HTTP status code may succeed
+// if reestablished, such as after its resource version expired, rather than
+// failing because of a problem with the request, such as missing permissions.
+func Retryable(code int) bool {
+	switch code {
+	case http.StatusGone, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestBackoff(t *testing.T) {
+	ctx := context.Background()
+	var b Backoff
+	for i := 0; i < 2; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b.delay != 4*minInterval {
+		t.Errorf("expected delay to double after each wait, got %s", b.delay)
+	}
+	b.Reset()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if b.delay != 2*minInterval {
+		t.Errorf("expected delay to start over after a reset, got %s", b.delay)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	b.delay = maxInterval
+	if err := b.Wait(canceled); err != context.Canceled {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{http.StatusGone, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusForbidden, false},
+		{http.StatusNotFound, false},
+		{http.StatusBadRequest, false},
+	}
+	for _, test := range tests {
+		if got := Retryable(test.code); got != test.want {
+			t.Errorf("Retryable(%d): got %v, want %v", test.code, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/ericchiang/k8s/apis/admissionregistration/v1beta1"
+	_ "github.com/ericchiang/k8s/apis/apiextensions/v1beta1"
+	_ "github.com/ericchiang/k8s/apis/events/v1beta1"
+	_ "github.com/ericchiang/k8s/apis/extensions/v1beta1"
+)
+
+// TestGolden decodes objects in the JSON format served by the API server, and
+// checks they encode to the same JSON.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob("testdata/golden/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no golden files found")
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objs, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: decode: %v", file, err)
+			continue
+		}
+		if len(objs) != 1 {
+			t.Errorf("%s: expected 1 object, got %d", file, len(objs))
+			continue
+		}
+		got, err := Marshal(objs[0])
+		if err != nil {
+			t.Errorf("%s: marshal: %v", file, err)
+			continue
+		}
+
+		var want, gotValue interface{}
+		if err := json.Unmarshal(data, &want); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(got, &gotValue); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want, gotValue) {
+			indented, _ := json.MarshalIndent(gotValue, "", "  ")
+			t.Errorf("%s: round trip didn't match, got:\n%s", file, indented)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+/*
+Package manifest decodes and encodes Kubernetes manifests, streams of YAML or
+JSON documents such as those passed to "kubectl apply -f".
+
+Documents are decoded into the Go types registered with k8s.Register, based on
+their "apiVersion" and "kind" fields. API group packages must be imported for
+their types to be registered. Documents of unregistered kinds are decoded as
+*k8s.Unstructured values.
+
+	import (
+		"github.com/ericchiang/k8s/manifest"
+		appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+		corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	)
+
+	func loadManifests(r io.Reader) error {
+		objs, err := manifest.Decode(r)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			switch obj := obj.(type) {
+			case *appsv1.Deployment:
+				fmt.Println("deployment", *obj.Metadata.Name)
+			case *corev1.Service:
+				fmt.Println("service", *obj.Metadata.Name)
+			}
+		}
+		return nil
+	}
+
+*/
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ericchiang/k8s"
+	"github.com/ghodss/yaml"
+)
+
+// Decode decodes all documents in a stream.
+func Decode(r io.Reader) ([]k8s.Resource, error) {
+	d := NewDecoder(r)
+	var objs []k8s.Resource
+	for {
+		obj, err := d.Decode()
+		if err != nil {
+			if err == io.EOF {
+				return objs, nil
+			}
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+}
+
+// Decoder reads objects from a stream of YAML or JSON documents. YAML documents
+// are separated by "---" lines. Objects of kind "List" are flattened into their
+// items.
+type Decoder struct {
+	r *bufio.Reader
+
+	// Objects decoded but not yet returned, such as the remaining items of a
+	// list or additional objects of a JSON stream.
+	pending []json.RawMessage
+
+	// Number of the document being decoded, for error messages.
+	doc int
+}
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode returns the next object in the stream, or io.EOF when the stream is
+// exhausted.
+func (d *Decoder) Decode() (k8s.Resource, error) {
+	for len(d.pending) == 0 {
+		doc, err := d.nextDocument()
+		if err != nil {
+			return nil, err
+		}
+		d.doc++
+		objs, err := splitDocument(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %v", d.doc, err)
+		}
+		d.pending = objs
+	}
+	data := d.pending[0]
+	d.pending = d.pending[1:]
+
+	obj, err := decodeObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("document %d: %v", d.doc, err)
+	}
+	return obj, nil
+}
+
+// nextDocument reads the next non-empty YAML document.
+func (d *Decoder) nextDocument() ([]byte, error) {
+	var (
+		buf     bytes.Buffer
+		content bool
+	)
+	for {
+		line, err := d.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if isSeparator(line) {
+			if content {
+				return buf.Bytes(), nil
+			}
+			buf.Reset()
+		} else {
+			buf.WriteString(line)
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				content = true
+			}
+		}
+		if err == io.EOF {
+			if content {
+				return buf.Bytes(), nil
+			}
+			return nil, io.EOF
+		}
+	}
+}
+
+func isSeparator(line string) bool {
+	if !strings.HasPrefix(line, "---") {
+		return false
+	}
+	rest := strings.TrimSpace(line[3:])
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
+
+// splitDocument converts a document into one or more JSON objects. JSON
+// documents may contain multiple concatenated objects, and lists are expanded.
+func splitDocument(doc []byte) ([]json.RawMessage, error) {
+	var raw []json.RawMessage
+	if trimmed := bytes.TrimSpace(doc); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		for {
+			var obj json.RawMessage
+			if err := dec.Decode(&obj); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, fmt.Errorf("parse json: %v", err)
+			}
+			raw = append(raw, obj)
+		}
+	} else {
+		data, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("parse yaml: %v", err)
+		}
+		raw = append(raw, data)
+	}
+
+	var objs []json.RawMessage
+	for _, obj := range raw {
+		var list struct {
+			Kind  string             `json:"kind"`
+			Items *[]json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(obj, &list); err != nil {
+			return nil, fmt.Errorf("expected object: %v", err)
+		}
+		if list.Items != nil && strings.HasSuffix(list.Kind, "List") {
+			objs = append(objs, *list.Items...)
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+func decodeObject(data []byte) (k8s.Resource, error) {
+	var typeMeta struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &typeMeta); err != nil {
+		return nil, fmt.Errorf("expected object: %v", err)
+	}
+	if typeMeta.APIVersion == "" || typeMeta.Kind == "" {
+		return nil, errors.New("object has no apiVersion or kind")
+	}
+
+	gvk := parseKind(typeMeta.APIVersion, typeMeta.Kind)
+	obj, ok := k8s.NewResource(gvk)
+	if !ok {
+		obj = new(k8s.Unstructured)
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, fmt.Errorf("decode %s: %v", gvk, err)
+	}
+	return obj, nil
+}
+
+func parseKind(apiVersion, kind string) k8s.GroupVersionKind {
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		return k8s.GroupVersionKind{Group: apiVersion[:i], Version: apiVersion[i+1:], Kind: kind}
+	}
+	return k8s.GroupVersionKind{Version: apiVersion, Kind: kind}
+}
+
+// Encoder writes objects to a stream as YAML documents, separated by "---"
+// lines.
+type Encoder struct {
+	w       io.Writer
+	written bool
+}
+
+// NewEncoder returns an encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes an object as a YAML document. The object must be a registered
+// type or an *k8s.Unstructured with its apiVersion and kind set.
+func (e *Encoder) Encode(obj k8s.Resource) error {
+	data, err := Marshal(obj)
+	if err != nil {
+		return err
+	}
+	out, err := yaml.JSONToYAML(data)
+	if err != nil {
+		return fmt.Errorf("convert to yaml: %v", err)
+	}
+	if e.written {
+		out = append([]byte("---\n"), out...)
+	}
+	if _, err := e.w.Write(out); err != nil {
+		return err
+	}
+	e.written = true
+	return nil
+}
+
+// Encode writes objects to w as a stream of YAML documents.
+func Encode(w io.Writer, objs ...k8s.Resource) error {
+	e := NewEncoder(w)
+	for _, obj := range objs {
+		if err := e.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Marshal encodes an object as JSON, including its "apiVersion" and "kind"
+// fields, which registered types don't hold.
+func Marshal(obj k8s.Resource) ([]byte, error) {
+	if u, ok := obj.(*k8s.Unstructured); ok {
+		if u.GetAPIVersion() == "" || u.GetKind() == "" {
+			return nil, errors.New("unstructured object has no apiVersion or kind")
+		}
+		return json.Marshal(u)
+	}
+	if _, ok := k8s.GroupVersionKindFor(obj); !ok {
+		return nil, fmt.Errorf("unregistered type %T", obj)
+	}
+	return k8s.EncodeJSON(obj)
+}
@@ -0,0 +1,220 @@
+package manifest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+const testManifest = `# A namespace
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: my-namespace
+---
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-deployment
+  namespace: my-namespace
+spec:
+  replicas: 3
+--- # custom resources are decoded as unstructured objects
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+spec:
+  size: 5
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cm-1
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cm-2
+---
+{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "secret-1"}}
+{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "secret-2"}}
+`
+
+func TestDecode(t *testing.T) {
+	objs, err := Decode(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	want := []struct {
+		name string
+		typ  string
+	}{
+		{"my-namespace", "*v1.Namespace"},
+		{"my-deployment", "*v1.Deployment"},
+		{"my-widget", "*k8s.Unstructured"},
+		{"cm-1", "*v1.ConfigMap"},
+		{"cm-2", "*v1.ConfigMap"},
+		{"secret-1", "*v1.Secret"},
+		{"secret-2", "*v1.Secret"},
+	}
+	if len(objs) != len(want) {
+		t.Fatalf("expected %d objects, got %d", len(want), len(objs))
+	}
+	for i, obj := range objs {
+		if got := obj.GetMetadata().GetName(); got != want[i].name {
+			t.Errorf("object %d: wanted name=%q, got=%q", i, want[i].name, got)
+		}
+		if got := typeString(obj); got != want[i].typ {
+			t.Errorf("object %d: wanted type=%s, got=%s", i, want[i].typ, got)
+		}
+	}
+
+	deployment := objs[1].(*appsv1.Deployment)
+	if got := deployment.GetSpec().GetReplicas(); got != 3 {
+		t.Errorf("expected deployment to have 3 replicas, got %d", got)
+	}
+}
+
+func typeString(obj k8s.Resource) string {
+	switch obj.(type) {
+	case *corev1.Namespace:
+		return "*v1.Namespace"
+	case *corev1.ConfigMap:
+		return "*v1.ConfigMap"
+	case *corev1.Secret:
+		return "*v1.Secret"
+	case *appsv1.Deployment:
+		return "*v1.Deployment"
+	case *k8s.Unstructured:
+		return "*k8s.Unstructured"
+	}
+	return "unknown"
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []string{
+		"metadata:\n  name: foo\n",
+		"apiVersion: v1\nkind: ConfigMap\nmetadata: [\n",
+		"- foo\n- bar\n",
+	}
+	for _, test := range tests {
+		if _, err := Decode(strings.NewReader(test)); err == nil {
+			t.Errorf("expected error decoding %q", test)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-configmap"),
+			Namespace: k8s.String("my-namespace"),
+		},
+		Data: map[string]string{"foo": "bar"},
+	}
+	widget := new(k8s.Unstructured)
+	widget.SetAPIVersion("example.com/v1")
+	widget.SetKind("Widget")
+	widget.SetName("my-widget")
+
+	buf := new(bytes.Buffer)
+	if err := Encode(buf, cm, widget); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	want := `apiVersion: v1
+data:
+  foo: bar
+kind: ConfigMap
+metadata:
+  name: my-configmap
+  namespace: my-namespace
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+`
+	if got := buf.String(); got != want {
+		t.Errorf("wanted:\n%s\ngot:\n%s", want, got)
+	}
+
+	objs, err := Decode(buf)
+	if err != nil {
+		t.Fatalf("decode encoded objects: %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(objs))
+	}
+	if got, ok := objs[0].(*corev1.ConfigMap); !ok || got.Data["foo"] != "bar" {
+		t.Errorf("expected round tripped configmap, got %#v", objs[0])
+	}
+}
+
+// IntOrString and Quantity fields use their bare API encodings.
+func TestWireTypes(t *testing.T) {
+	const manifest = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-service
+spec:
+  ports:
+  - port: 80
+    targetPort: http
+  - port: 443
+    targetPort: 8443
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: my-pod
+spec:
+  containers:
+  - name: app
+    resources:
+      limits:
+        cpu: 0.5
+        memory: 1.5Gi
+`
+	objs, err := Decode(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	svc := objs[0].(*corev1.Service)
+	ports := svc.GetSpec().GetPorts()
+	if got := ports[0].GetTargetPort().StringValue(); got != "http" {
+		t.Errorf("expected target port http, got %q", got)
+	}
+	if got := ports[1].GetTargetPort().IntValue(); got != 8443 {
+		t.Errorf("expected target port 8443, got %d", got)
+	}
+	pod := objs[1].(*corev1.Pod)
+	limits := pod.GetSpec().GetContainers()[0].GetResources().GetLimits()
+	if got := limits["cpu"].MilliValue(); got != 500 {
+		t.Errorf("expected cpu limit of 500m, got %dm", got)
+	}
+
+	want := []string{
+		`"ports":[{"port":80,"targetPort":"http"},{"port":443,"targetPort":8443}]`,
+		`"limits":{"cpu":"500m","memory":"1536Mi"}`,
+	}
+	for i, obj := range objs {
+		data, err := Marshal(obj)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if !strings.Contains(string(data), want[i]) {
+			t.Errorf("expected %s in %s", want[i], data)
+		}
+	}
+}
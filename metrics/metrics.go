@@ -0,0 +1,232 @@
+/*
+Package metrics collects metrics about the requests made by a client, and
+serves them in the Prometheus text format.
+
+	collector := metrics.NewCollector()
+	client.Hooks = append(client.Hooks, collector.ForClient("my-controller"))
+	http.Handle("/metrics", collector)
+
+The following metrics are exported, labeled by client, verb, API group and
+resource:
+
+	k8s_client_requests_total            Counter of requests, also labeled by status code.
+	k8s_client_request_duration_seconds  Histogram of request latencies.
+	k8s_client_request_bytes_total       Counter of request body bytes.
+	k8s_client_response_bytes_total      Counter of response body bytes.
+
+Requests which fail before receiving a response have the code "error". Watch
+durations cover the lifetime of the watch, and are better excluded when
+graphing latencies.
+*/
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ericchiang/k8s"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of the
+// request duration histogram.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector is a k8s.RequestHook which records metrics about requests, and an
+// http.Handler which serves them. A Collector is safe for concurrent use, and
+// may be shared by multiple clients.
+type Collector struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[resourceKey]*histogram
+	reqBytes  map[resourceKey]uint64
+	respBytes map[resourceKey]uint64
+}
+
+type resourceKey struct {
+	client   string
+	verb     string
+	group    string
+	resource string
+}
+
+type requestKey struct {
+	resourceKey
+	code string
+}
+
+type histogram struct {
+	counts []uint64 // Per bucket, not cumulative.
+	count  uint64
+	sum    float64
+}
+
+// NewCollector returns a collector using DefaultBuckets.
+func NewCollector() *Collector {
+	return NewCollectorWithBuckets(DefaultBuckets)
+}
+
+// NewCollectorWithBuckets returns a collector using the given duration buckets,
+// which must be sorted in increasing order.
+func NewCollectorWithBuckets(buckets []float64) *Collector {
+	return &Collector{
+		buckets:   append([]float64(nil), buckets...),
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[resourceKey]*histogram),
+		reqBytes:  make(map[resourceKey]uint64),
+		respBytes: make(map[resourceKey]uint64),
+	}
+}
+
+// OnRequest implements k8s.RequestHook, recording requests with an empty
+// client label.
+func (c *Collector) OnRequest(info *k8s.RequestInfo) {
+	c.record("", info)
+}
+
+// ForClient returns a hook recording requests with the given client label,
+// allowing the requests of multiple clients to be told apart.
+func (c *Collector) ForClient(name string) k8s.RequestHook {
+	return k8s.RequestHookFunc(func(info *k8s.RequestInfo) {
+		c.record(name, info)
+	})
+}
+
+func (c *Collector) record(client string, info *k8s.RequestInfo) {
+	rk := resourceKey{
+		client:   client,
+		verb:     info.Verb,
+		group:    info.Group,
+		resource: info.Resource,
+	}
+	if info.Subresource != "" {
+		rk.resource += "/" + info.Subresource
+	}
+	code := "error"
+	if info.Err == nil {
+		code = strconv.Itoa(info.StatusCode)
+	}
+	seconds := info.Duration.Seconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests[requestKey{rk, code}]++
+	h, ok := c.durations[rk]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.durations[rk] = h
+	}
+	for i, le := range c.buckets {
+		if seconds <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+	c.reqBytes[rk] += uint64(info.RequestBytes)
+	c.respBytes[rk] += uint64(info.ResponseBytes)
+}
+
+// ServeHTTP writes the collected metrics in the Prometheus text format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	c.write(bw)
+	bw.Flush()
+}
+
+func (c *Collector) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP k8s_client_requests_total Number of requests to the API server, by status code.")
+	fmt.Fprintln(w, "# TYPE k8s_client_requests_total counter")
+	reqKeys := make([]requestKey, 0, len(c.requests))
+	for k := range c.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		if reqKeys[i].resourceKey != reqKeys[j].resourceKey {
+			return reqKeys[i].resourceKey.less(reqKeys[j].resourceKey)
+		}
+		return reqKeys[i].code < reqKeys[j].code
+	})
+	for _, k := range reqKeys {
+		fmt.Fprintf(w, "k8s_client_requests_total{%s,code=%s} %d\n", k.labels(), quote(k.code), c.requests[k])
+	}
+
+	fmt.Fprintln(w, "# HELP k8s_client_request_duration_seconds Latency of requests to the API server.")
+	fmt.Fprintln(w, "# TYPE k8s_client_request_duration_seconds histogram")
+	for _, k := range sortedKeys(c.durations) {
+		h := c.durations[k]
+		labels := k.labels()
+		var cumulative uint64
+		for i, le := range c.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "k8s_client_request_duration_seconds_bucket{%s,le=%s} %d\n",
+				labels, quote(strconv.FormatFloat(le, 'g', -1, 64)), cumulative)
+		}
+		fmt.Fprintf(w, "k8s_client_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(w, "k8s_client_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "k8s_client_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	writeCounter(w, "k8s_client_request_bytes_total", "Size of request bodies sent to the API server.", c.reqBytes)
+	writeCounter(w, "k8s_client_response_bytes_total", "Size of response bodies received from the API server.", c.respBytes)
+}
+
+func writeCounter(w io.Writer, name, help string, values map[resourceKey]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	keys := make([]resourceKey, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s{%s} %d\n", name, k.labels(), values[k])
+	}
+}
+
+func sortedKeys(m map[resourceKey]*histogram) []resourceKey {
+	keys := make([]resourceKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	return keys
+}
+
+func (k resourceKey) less(o resourceKey) bool {
+	if k.client != o.client {
+		return k.client < o.client
+	}
+	if k.verb != o.verb {
+		return k.verb < o.verb
+	}
+	if k.group != o.group {
+		return k.group < o.group
+	}
+	return k.resource < o.resource
+}
+
+func (k resourceKey) labels() string {
+	return fmt.Sprintf("client=%s,verb=%s,group=%s,resource=%s",
+		quote(k.client), quote(k.verb), quote(k.group), quote(k.resource))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote quotes a label value as required by the Prometheus text format.
+func quote(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
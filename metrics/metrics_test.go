@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+func TestCollector(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+
+	collector := NewCollector()
+	client := srv.Client()
+	client.Hooks = append(client.Hooks, collector.ForClient("test"))
+
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-configmap"),
+			Namespace: k8s.String("default"),
+		},
+	}
+	if err := client.Create(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := client.Get(ctx, "default", "my-configmap", cm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.Get(ctx, "default", "missing", cm); err == nil {
+		t.Fatal("expected not found error")
+	}
+
+	rr := httptest.NewRecorder()
+	collector.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	body, err := ioutil.ReadAll(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(body)
+
+	labels := `client="test",verb="get",group="",resource="configmaps"`
+	for _, want := range []string{
+		`k8s_client_requests_total{client="test",verb="create",group="",resource="configmaps",code="201"} 1`,
+		`k8s_client_requests_total{` + labels + `,code="200"} 2`,
+		`k8s_client_requests_total{` + labels + `,code="404"} 1`,
+		`k8s_client_request_duration_seconds_bucket{` + labels + `,le="+Inf"} 3`,
+		`k8s_client_request_duration_seconds_count{` + labels + `} 3`,
+		`# TYPE k8s_client_request_duration_seconds histogram`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("metrics didn't contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `k8s_client_request_bytes_total{client="test",verb="create",group="",resource="configmaps"} 0`) {
+		t.Errorf("expected request bytes for create:\n%s", out)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	c := NewCollectorWithBuckets([]float64{0.1, 1})
+	for _, d := range []time.Duration{50 * time.Millisecond, 500 * time.Millisecond, 5 * time.Second} {
+		c.OnRequest(&k8s.RequestInfo{Verb: "list", Resource: "pods", StatusCode: 200, Duration: d})
+	}
+	c.OnRequest(&k8s.RequestInfo{Verb: "list", Resource: "pods", Err: errors.New("connection refused")})
+
+	var b strings.Builder
+	c.write(&b)
+	labels := `client="",verb="list",group="",resource="pods"`
+	for _, want := range []string{
+		`k8s_client_requests_total{` + labels + `,code="200"} 3`,
+		`k8s_client_requests_total{` + labels + `,code="error"} 1`,
+		`k8s_client_request_duration_seconds_bucket{` + labels + `,le="0.1"} 2`,
+		`k8s_client_request_duration_seconds_bucket{` + labels + `,le="1"} 3`,
+		`k8s_client_request_duration_seconds_bucket{` + labels + `,le="+Inf"} 4`,
+		`k8s_client_request_duration_seconds_sum{` + labels + `} 5.55`,
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("metrics didn't contain %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got, want := quote("a\"b\\c\nd"), `"a\"b\\c\nd"`; got != want {
+		t.Errorf("quote: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,317 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPISchema is the OpenAPI v2 document published by the API server, indexed
+// by the kinds each definition describes.
+type OpenAPISchema struct {
+	// Definitions holds every schema definition by name, for example
+	// "io.k8s.api.apps.v1.Deployment".
+	Definitions map[string]*Schema
+
+	kinds map[GroupVersionKind]string
+}
+
+// Schema is a JSON schema definition within an OpenAPI document. Only the subset
+// of JSON schema used by Kubernetes is supported.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+
+	GroupVersionKinds     []GroupVersionKind `json:"x-kubernetes-group-version-kind,omitempty"`
+	PreserveUnknownFields bool               `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+}
+
+// OpenAPISchema fetches and parses the OpenAPI v2 document served at
+// "/openapi/v2". The schema is always requested as JSON.
+//
+// The document is large, and should be fetched once and reused. A discovery
+// client created with NewCachedDiscoveryClient caches it like any other
+// discovery response.
+func (d *Discovery) OpenAPISchema(ctx context.Context) (*OpenAPISchema, error) {
+	var doc struct {
+		Definitions map[string]*Schema `json:"definitions"`
+	}
+	if err := d.get(ctx, "openapi/v2", &doc); err != nil {
+		return nil, err
+	}
+	return newOpenAPISchema(doc.Definitions), nil
+}
+
+func newOpenAPISchema(definitions map[string]*Schema) *OpenAPISchema {
+	s := &OpenAPISchema{Definitions: definitions, kinds: map[GroupVersionKind]string{}}
+	for name, def := range definitions {
+		for _, gvk := range def.GroupVersionKinds {
+			s.kinds[gvk] = name
+		}
+	}
+	return s
+}
+
+// Lookup returns the schema of a kind.
+func (s *OpenAPISchema) Lookup(gvk GroupVersionKind) (*Schema, bool) {
+	name, ok := s.kinds[gvk]
+	if !ok {
+		return nil, false
+	}
+	return s.Definitions[name], true
+}
+
+// FieldError is a validation error for a specific field of an object.
+type FieldError struct {
+	// Path to the field, such as "spec.template.spec.containers[0].image".
+	Path    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError is returned when an object doesn't match its schema.
+type ValidationError struct {
+	Kind   GroupVersionKind
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	errs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err.Error()
+	}
+	return fmt.Sprintf("invalid %s: %s", e.Kind, strings.Join(errs, "; "))
+}
+
+// Validate checks an object against the schema of its kind. It reports unknown
+// fields, fields of the wrong type, and missing required fields, returning a
+// *ValidationError listing each.
+//
+// The object can be an *Unstructured, a decoded JSON object, or any value that
+// encodes to JSON, such as a custom resource type.
+func (s *OpenAPISchema) Validate(gvk GroupVersionKind, obj interface{}) error {
+	schema, ok := s.Lookup(gvk)
+	if !ok {
+		return fmt.Errorf("no schema for %s", gvk)
+	}
+	v, err := toJSONValue(obj)
+	if err != nil {
+		return err
+	}
+	var errs []*FieldError
+	s.validate(schema, "", v, &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Kind: gvk, Errors: errs}
+	}
+	return nil
+}
+
+// ValidateUnstructured checks an object against the schema of the kind named by
+// its apiVersion and kind fields.
+func (s *OpenAPISchema) ValidateUnstructured(u *Unstructured) error {
+	group, version := parseGroupVersion(u.GetAPIVersion())
+	return s.Validate(GroupVersionKind{group, version, u.GetKind()}, u)
+}
+
+// ValidateResource checks an object against the schema of its kind. The kind of
+// registered types is looked up with GroupVersionKindFor, while *Unstructured
+// objects are validated using their apiVersion and kind fields.
+func (s *OpenAPISchema) ValidateResource(r Resource) error {
+	if u, ok := r.(*Unstructured); ok {
+		return s.ValidateUnstructured(u)
+	}
+	gvk, ok := GroupVersionKindFor(r)
+	if !ok {
+		return fmt.Errorf("unregistered type %T", r)
+	}
+	return s.Validate(gvk, r)
+}
+
+// toJSONValue converts an object into the generic form produced by decoding JSON.
+func toJSONValue(obj interface{}) (interface{}, error) {
+	switch obj := obj.(type) {
+	case *Unstructured:
+		return obj.Object, nil
+	case map[string]interface{}:
+		return obj, nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("encode object: %v", err)
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decode object: %v", err)
+	}
+	return v, nil
+}
+
+// resolve follows references to other definitions.
+func (s *OpenAPISchema) resolve(schema *Schema) (string, *Schema) {
+	name := ""
+	for schema != nil && schema.Ref != "" {
+		name = strings.TrimPrefix(schema.Ref, "#/definitions/")
+		schema = s.Definitions[name]
+	}
+	return name, schema
+}
+
+func (s *OpenAPISchema) validate(schema *Schema, path string, v interface{}, errs *[]*FieldError) {
+	name, schema := s.resolve(schema)
+	if schema == nil || v == nil {
+		// Unresolvable references are ignored, and null is valid for any
+		// optional field.
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Types which accept multiple JSON representations.
+	switch {
+	case schema.Format == "int-or-string", strings.HasSuffix(name, ".util.intstr.IntOrString"):
+		if !isJSONString(v) && !isJSONInteger(v) {
+			fail("expected integer or string, got %s", jsonType(v))
+		}
+		return
+	case strings.HasSuffix(name, ".api.resource.Quantity"):
+		if !isJSONString(v) && !isJSONNumber(v) {
+			fail("expected quantity, got %s", jsonType(v))
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "string":
+		if !isJSONString(v) {
+			fail("expected string, got %s", jsonType(v))
+		}
+	case "integer":
+		if !isJSONInteger(v) {
+			fail("expected integer, got %s", jsonType(v))
+		}
+	case "number":
+		if !isJSONNumber(v) {
+			fail("expected number, got %s", jsonType(v))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("expected boolean, got %s", jsonType(v))
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			fail("expected array, got %s", jsonType(v))
+			return
+		}
+		for i, item := range items {
+			s.validate(schema.Items, path+"["+strconv.Itoa(i)+"]", item, errs)
+		}
+	case "object", "":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			if schema.Type == "object" {
+				fail("expected object, got %s", jsonType(v))
+			}
+			return
+		}
+		s.validateObject(schema, path, obj, errs)
+	}
+}
+
+func (s *OpenAPISchema) validateObject(schema *Schema, path string, obj map[string]interface{}, errs *[]*FieldError) {
+	for _, field := range schema.Required {
+		if _, ok := obj[field]; !ok {
+			*errs = append(*errs, &FieldError{Path: joinFieldPath(path, field), Message: "required field is missing"})
+		}
+	}
+
+	// Sort keys so errors are reported in a stable order.
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fieldPath := joinFieldPath(path, k)
+		if prop, ok := schema.Properties[k]; ok {
+			s.validate(prop, fieldPath, obj[k], errs)
+			continue
+		}
+		switch {
+		case schema.AdditionalProperties != nil:
+			s.validate(schema.AdditionalProperties, fieldPath, obj[k], errs)
+		case len(schema.Properties) > 0 && !schema.PreserveUnknownFields:
+			*errs = append(*errs, &FieldError{Path: fieldPath, Message: "unknown field"})
+		}
+	}
+}
+
+func joinFieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func isJSONString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func isJSONNumber(v interface{}) bool {
+	switch v.(type) {
+	case json.Number, float64, float32, int, int32, int64:
+		return true
+	}
+	return false
+}
+
+func isJSONInteger(v interface{}) bool {
+	switch v := v.(type) {
+	case json.Number:
+		_, err := v.Int64()
+		return err == nil
+	case float64:
+		return v == float64(int64(v))
+	case int, int32, int64:
+		return true
+	}
+	return false
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if isJSONNumber(v) {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
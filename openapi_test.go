@@ -0,0 +1,201 @@
+package k8s
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+const testOpenAPIDefinitions = `{
+	"io.k8s.api.core.v1.ConfigMap": {
+		"type": "object",
+		"properties": {
+			"apiVersion": {"type": "string"},
+			"kind": {"type": "string"},
+			"metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"},
+			"data": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"x-kubernetes-group-version-kind": [{"group": "", "kind": "ConfigMap", "version": "v1"}]
+	},
+	"io.k8s.api.core.v1.ServicePort": {
+		"type": "object",
+		"required": ["port"],
+		"properties": {
+			"name": {"type": "string"},
+			"port": {"type": "integer", "format": "int32"},
+			"targetPort": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.util.intstr.IntOrString"}
+		}
+	},
+	"io.k8s.api.core.v1.Service": {
+		"type": "object",
+		"properties": {
+			"apiVersion": {"type": "string"},
+			"kind": {"type": "string"},
+			"metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"},
+			"spec": {
+				"type": "object",
+				"properties": {
+					"ports": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.api.core.v1.ServicePort"}},
+					"publishNotReadyAddresses": {"type": "boolean"}
+				}
+			}
+		},
+		"x-kubernetes-group-version-kind": [{"group": "", "kind": "Service", "version": "v1"}]
+	},
+	"com.example.v1.Custom": {
+		"type": "object",
+		"properties": {
+			"apiVersion": {"type": "string"},
+			"kind": {"type": "string"},
+			"metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}
+		},
+		"x-kubernetes-group-version-kind": [{"group": "example.com", "kind": "Custom", "version": "v1"}]
+	},
+	"io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"namespace": {"type": "string"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+		}
+	},
+	"io.k8s.apimachinery.pkg.util.intstr.IntOrString": {
+		"type": "string",
+		"format": "int-or-string"
+	}
+}`
+
+func newTestOpenAPISchema(t *testing.T) *OpenAPISchema {
+	var definitions map[string]*Schema
+	if err := json.Unmarshal([]byte(testOpenAPIDefinitions), &definitions); err != nil {
+		t.Fatalf("parse definitions: %v", err)
+	}
+	return newOpenAPISchema(definitions)
+}
+
+func TestOpenAPILookup(t *testing.T) {
+	s := newTestOpenAPISchema(t)
+	schema, ok := s.Lookup(GroupVersionKind{"", "v1", "ConfigMap"})
+	if !ok {
+		t.Fatalf("expected to find schema for configmaps")
+	}
+	if _, ok := schema.Properties["data"]; !ok {
+		t.Errorf("expected configmap schema to have a data field")
+	}
+	if _, ok := s.Lookup(GroupVersionKind{"apps", "v1", "ConfigMap"}); ok {
+		t.Errorf("expected no schema for apps/v1 configmaps")
+	}
+}
+
+func TestOpenAPIValidate(t *testing.T) {
+	s := newTestOpenAPISchema(t)
+
+	tests := []struct {
+		name string
+		obj  string
+		want []*FieldError
+	}{
+		{
+			name: "valid-configmap",
+			obj:  `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"foo"},"data":{"foo":"bar"}}`,
+		},
+		{
+			name: "valid-service",
+			obj: `{"apiVersion":"v1","kind":"Service","spec":{"ports":[
+				{"port":80,"targetPort":8080},
+				{"port":443,"targetPort":"https"}
+			]}}`,
+		},
+		{
+			name: "unknown-field",
+			obj:  `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"foo","nmespace":"bar"}}`,
+			want: []*FieldError{
+				{Path: "metadata.nmespace", Message: "unknown field"},
+			},
+		},
+		{
+			name: "wrong-types",
+			obj: `{"apiVersion":"v1","kind":"Service","spec":{
+				"publishNotReadyAddresses":"true",
+				"ports":[{"port":"80","targetPort":true}]
+			}}`,
+			want: []*FieldError{
+				{Path: "spec.ports[0].port", Message: "expected integer, got string"},
+				{Path: "spec.ports[0].targetPort", Message: "expected integer or string, got boolean"},
+				{Path: "spec.publishNotReadyAddresses", Message: "expected boolean, got string"},
+			},
+		},
+		{
+			name: "missing-required",
+			obj:  `{"apiVersion":"v1","kind":"Service","spec":{"ports":[{"name":"http"}]}}`,
+			want: []*FieldError{
+				{Path: "spec.ports[0].port", Message: "required field is missing"},
+			},
+		},
+		{
+			name: "additional-properties",
+			obj:  `{"apiVersion":"v1","kind":"ConfigMap","data":{"foo":1}}`,
+			want: []*FieldError{
+				{Path: "data.foo", Message: "expected string, got number"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := new(Unstructured)
+			if err := json.Unmarshal([]byte(test.obj), u); err != nil {
+				t.Fatalf("parse object: %v", err)
+			}
+			err := s.ValidateUnstructured(u)
+			if test.want == nil {
+				if err != nil {
+					t.Errorf("expected object to be valid: %v", err)
+				}
+				return
+			}
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			if !reflect.DeepEqual(verr.Errors, test.want) {
+				t.Errorf("wanted errors=%v, got=%v", test.want, verr.Errors)
+			}
+		})
+	}
+}
+
+func TestOpenAPIValidateResource(t *testing.T) {
+	s := newTestOpenAPISchema(t)
+
+	valid := &CustomResource{Metadata: &metav1.ObjectMeta{Name: String("foo")}}
+	if err := s.ValidateResource(valid); err != nil {
+		t.Errorf("expected object to be valid: %v", err)
+	}
+
+	invalid := &CustomResource{Metadata: &metav1.ObjectMeta{GenerateName: String("foo-")}}
+	err := s.ValidateResource(invalid)
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	want := GroupVersionKind{"example.com", "v1", "Custom"}
+	if verr.Kind != want {
+		t.Errorf("wanted kind %s, got %s", want, verr.Kind)
+	}
+	wantErrs := []*FieldError{{Path: "metadata.generateName", Message: "unknown field"}}
+	if !reflect.DeepEqual(verr.Errors, wantErrs) {
+		t.Errorf("wanted errors=%v, got=%v", wantErrs, verr.Errors)
+	}
+
+	u := &Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"data":       map[string]interface{}{"foo": 1},
+	}}
+	if _, ok := s.ValidateResource(u).(*ValidationError); !ok {
+		t.Errorf("expected unstructured object to be validated by its kind")
+	}
+}
@@ -0,0 +1,87 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/ericchiang/k8s"
+	"github.com/ericchiang/k8s/internal/apipath"
+	"github.com/ericchiang/k8s/runtime"
+	"github.com/golang/protobuf/proto"
+)
+
+const contentTypePB = "application/vnd.kubernetes.protobuf"
+
+var magicBytes = []byte{0x6b, 0x38, 0x73, 0x00}
+
+// matches reports if a request matches a recorded interaction.
+func matches(in *interaction, req *http.Request, reqBody []byte) bool {
+	if in.Request.Method != req.Method {
+		return false
+	}
+	u, err := url.Parse(in.Request.URL)
+	if err != nil || u.Path != req.URL.Path {
+		return false
+	}
+	// Compare queries after parsing, since parameter order isn't significant.
+	if u.Query().Encode() != req.URL.Query().Encode() {
+		return false
+	}
+	return bodiesEqual(req, in.Request.Body, reqBody)
+}
+
+// bodiesEqual compares request bodies by value rather than encoding. JSON
+// objects may order keys differently and protobuf encodes maps in random order,
+// so identical requests don't always produce identical bytes.
+func bodiesEqual(req *http.Request, a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case contentType == contentTypePB:
+		return protobufEqual(req.URL.Path, a, b)
+	case strings.HasSuffix(contentType, "json"):
+		var va, vb interface{}
+		if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+			return false
+		}
+		return reflect.DeepEqual(va, vb)
+	}
+	return false
+}
+
+// protobufEqual decodes protobuf bodies using the type registered for the
+// request's resource.
+func protobufEqual(path string, a, b []byte) bool {
+	p, ok := apipath.Parse(path)
+	if !ok {
+		return false
+	}
+	mapping, ok := k8s.RESTMappingFor(k8s.GroupVersionResource{Group: p.Group, Version: p.Version, Resource: p.Resource})
+	if !ok {
+		return false
+	}
+	newMessage := func(data []byte) proto.Message {
+		obj, ok := k8s.NewResource(mapping.Kind)
+		if !ok {
+			return nil
+		}
+		msg, ok := obj.(proto.Message)
+		if !ok || !bytes.HasPrefix(data, magicBytes) {
+			return nil
+		}
+		var u runtime.Unknown
+		if proto.Unmarshal(data[len(magicBytes):], &u) != nil || proto.Unmarshal(u.Raw, msg) != nil {
+			return nil
+		}
+		return msg
+	}
+	ma, mb := newMessage(a), newMessage(b)
+	return ma != nil && mb != nil && proto.Equal(ma, mb)
+}
@@ -0,0 +1,351 @@
+/*
+Package recorder records the HTTP interactions of a client with an API server,
+and replays them later without a cluster.
+
+Tests typically record against a live cluster once, then replay the recording
+in CI.
+
+	var record = flag.Bool("record", false, "record interactions against a live cluster")
+
+	func TestMyController(t *testing.T) {
+		mode := recorder.Replay
+		var live *k8s.Client
+		if *record {
+			mode = recorder.Record
+			live = newLiveClient(t)
+		}
+		rec, err := recorder.New("testdata/my-controller.json", mode, live)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rec.Close() // Writes the recording in record mode.
+
+		client := rec.Client()
+		// ...
+	}
+
+Requests are matched to recorded interactions by method, path, query and body.
+Each interaction is replayed at most once, in the order it was recorded, so a
+test making the same request twice sees both recorded responses. Watch streams
+are recorded as a sequence of events, and replayed with the same timing.
+
+Authorization headers aren't recorded, but response bodies are stored as is, so
+recordings of secrets shouldn't be committed.
+*/
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ericchiang/k8s"
+)
+
+// Mode determines whether a Recorder records or replays interactions.
+type Mode int
+
+const (
+	// Replay serves responses from a recording. No requests are sent.
+	Replay Mode = iota
+	// Record sends requests to the API server and records them.
+	Record
+)
+
+// replayEndpoint is used by replay clients created without a live client.
+const replayEndpoint = "https://kubernetes.invalid"
+
+// Recorder is an http.RoundTripper which records or replays interactions.
+type Recorder struct {
+	mode     Mode
+	filename string
+	client   *k8s.Client
+
+	// Transport used to send requests in record mode.
+	transport http.RoundTripper
+
+	mu        sync.Mutex
+	recording *recording
+	used      []bool
+}
+
+// recording is the file format of a recording.
+type recording struct {
+	// Namespace of the client used to record, so replay clients default to
+	// the same namespace.
+	Namespace    string         `json:"namespace,omitempty"`
+	Interactions []*interaction `json:"interactions"`
+}
+
+type interaction struct {
+	Request  request  `json:"request"`
+	Response response `json:"response"`
+}
+
+type request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"` // Path and query.
+	Header http.Header `json:"header,omitempty"`
+	Body   body        `json:"body,omitempty"`
+}
+
+type response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       body        `json:"body,omitempty"`
+
+	// Events of a watch stream, in place of a body.
+	Events []*event `json:"events,omitempty"`
+}
+
+type event struct {
+	// Time since the response was received.
+	Offset duration `json:"offset"`
+	Data   body     `json:"data"`
+}
+
+// body is encoded as a string when it's valid UTF-8, such as JSON, so
+// recordings are readable and diffable. Other bodies, such as protobuf, are
+// encoded as base64.
+type body []byte
+
+func (b body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(struct {
+		Base64 []byte `json:"base64"`
+	}{b})
+}
+
+func (b *body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = body(s)
+		return nil
+	}
+	var encoded struct {
+		Base64 []byte `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	*b = encoded.Base64
+	return nil
+}
+
+// duration is encoded as a string such as "1.5s".
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// New creates a recorder using the given file.
+//
+// In record mode, requests are sent using the live client c, and the recording
+// is written to the file by Close. In replay mode, the recording is read from
+// the file and c may be nil.
+func New(filename string, mode Mode, c *k8s.Client) (*Recorder, error) {
+	r := &Recorder{mode: mode, filename: filename}
+	switch mode {
+	case Record:
+		if c == nil {
+			return nil, errors.New("recorder: a client is required to record")
+		}
+		r.transport = http.DefaultTransport
+		if c.Client != nil && c.Client.Transport != nil {
+			r.transport = c.Client.Transport
+		}
+		r.recording = &recording{Namespace: c.Namespace}
+	case Replay:
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("recorder: read recording: %v", err)
+		}
+		r.recording = new(recording)
+		if err := json.Unmarshal(data, r.recording); err != nil {
+			return nil, fmt.Errorf("recorder: parse recording %s: %v", filename, err)
+		}
+		r.used = make([]bool, len(r.recording.Interactions))
+		if c == nil {
+			c = &k8s.Client{Endpoint: replayEndpoint, Namespace: r.recording.Namespace}
+		}
+	default:
+		return nil, fmt.Errorf("recorder: unknown mode %d", mode)
+	}
+
+	client := *c
+	httpClient := new(http.Client)
+	if c.Client != nil {
+		*httpClient = *c.Client
+	}
+	httpClient.Transport = r
+	client.Client = httpClient
+	r.client = &client
+	return r, nil
+}
+
+// Client returns a client which sends requests through the recorder.
+func (r *Recorder) Client() *k8s.Client {
+	return r.client
+}
+
+// Close writes the recording to the file in record mode. Watches still in
+// progress are recorded up to this point.
+func (r *Recorder) Close() error {
+	if r.mode != Record {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.recording, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("recorder: encode recording: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.filename), 0755); err != nil {
+		return fmt.Errorf("recorder: %v", err)
+	}
+	if err := ioutil.WriteFile(r.filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("recorder: write recording: %v", err)
+	}
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("recorder: read request body: %v", err)
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+	if r.mode == Record {
+		return r.record(req, reqBody)
+	}
+	return r.replay(req, reqBody)
+}
+
+func isWatch(req *http.Request) bool {
+	w := req.URL.Query().Get("watch")
+	return w == "true" || w == "1"
+}
+
+// recordedHeaders returns the headers to record, omitting credentials and
+// values which change between requests.
+func recordedHeaders(h http.Header, omit ...string) http.Header {
+	recorded := http.Header{}
+	for k, v := range h {
+		recorded[k] = v
+	}
+	for _, k := range omit {
+		recorded.Del(k)
+	}
+	if len(recorded) == 0 {
+		return nil
+	}
+	return recorded
+}
+
+func (r *Recorder) record(req *http.Request, reqBody []byte) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	in := &interaction{
+		Request: request{
+			Method: req.Method,
+			URL:    req.URL.RequestURI(),
+			Header: recordedHeaders(req.Header, "Authorization", "Cookie"),
+			Body:   reqBody,
+		},
+		Response: response{
+			StatusCode: resp.StatusCode,
+			Header:     recordedHeaders(resp.Header, "Date", "Set-Cookie"),
+		},
+	}
+	r.mu.Lock()
+	r.recording.Interactions = append(r.recording.Interactions, in)
+	r.mu.Unlock()
+
+	if isWatch(req) && resp.StatusCode/100 == 2 {
+		resp.Body = &watchRecorder{
+			r:           r,
+			resp:        &in.Response,
+			body:        resp.Body,
+			start:       time.Now(),
+			contentType: resp.Header.Get("Content-Type"),
+		}
+		return resp, nil
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("recorder: read response body: %v", err)
+	}
+	r.mu.Lock()
+	in.Response.Body = respBody
+	r.mu.Unlock()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, reqBody []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.recording.Interactions {
+		if r.used[i] || !matches(in, req, reqBody) {
+			continue
+		}
+		r.used[i] = true
+
+		resp := &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{},
+			Request:       req,
+			ContentLength: -1,
+		}
+		for k, v := range in.Response.Header {
+			resp.Header[k] = v
+		}
+		if in.Response.Events != nil {
+			resp.Body = newWatchReplayer(req.Context(), in.Response.Events)
+		} else {
+			resp.Body = ioutil.NopCloser(bytes.NewReader(in.Response.Body))
+			resp.ContentLength = int64(len(in.Response.Body))
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("recorder: no recorded interaction for %s %s", req.Method, req.URL.RequestURI())
+}
@@ -0,0 +1,128 @@
+package recorder
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+// session performs the same requests against a recording or replaying client,
+// returning a summary of the results.
+func session(t *testing.T, client *k8s.Client) []string {
+	ctx := context.Background()
+	var results []string
+
+	cm := &corev1.ConfigMap{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-configmap"),
+			Namespace: k8s.String(client.Namespace),
+		},
+		// Multiple keys, since protobuf encodes maps in a random order.
+		Data: map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"},
+	}
+	if err := client.Create(ctx, cm); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	results = append(results, "created "+cm.Metadata.GetUid())
+
+	var got corev1.ConfigMap
+	err := client.Get(ctx, client.Namespace, "missing", &got)
+	if apiErr, ok := err.(*k8s.APIError); !ok || apiErr.Code != http.StatusNotFound {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	w, err := client.Watch(ctx, client.Namespace, new(corev1.ConfigMap))
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer w.Close()
+	for i := 0; i < 2; i++ {
+		if i == 1 {
+			cm.Data["e"] = "5"
+			if err := client.Update(ctx, cm); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+		}
+		event := new(corev1.ConfigMap)
+		eventType, err := w.Next(event)
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		results = append(results, eventType+" "+event.Metadata.GetResourceVersion())
+	}
+	return results
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "testdata", "recording.json")
+
+	srv := fake.NewServer()
+	rec, err := New(filename, Record, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := session(t, rec.Client())
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Authorization") {
+		t.Errorf("recording contains credentials")
+	}
+
+	rep, err := New(filename, Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := session(t, rep.Client())
+	if strings.Join(recorded, ",") != strings.Join(replayed, ",") {
+		t.Errorf("replay didn't match recording\nrecorded: %q\nreplayed: %q", recorded, replayed)
+	}
+
+	// All interactions have been replayed.
+	var cm corev1.ConfigMap
+	err = rep.Client().Get(context.Background(), "default", "my-configmap", &cm)
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("expected error for unrecorded request, got %v", err)
+	}
+}
+
+func TestBodyEncoding(t *testing.T) {
+	tests := []body{
+		body(`{"kind":"Pod"}`),
+		body{0x6b, 0x38, 0x73, 0x00, 0xff, 0xfe},
+		nil,
+	}
+	for _, b := range tests {
+		data, err := b.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got body
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Fatalf("unmarshal %s: %v", data, err)
+		}
+		if string(got) != string(b) {
+			t.Errorf("round trip of %q: got %q", b, got)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watchRecorder records a watch stream as it's read by the client. The stream
+// is split into events, newline delimited for JSON and length prefixed for
+// protobuf, each recorded with the time it was received.
+type watchRecorder struct {
+	r           *Recorder
+	resp        *response
+	body        io.ReadCloser
+	start       time.Time
+	contentType string
+
+	buf []byte
+}
+
+func (w *watchRecorder) Read(p []byte) (int, error) {
+	n, err := w.body.Read(p)
+	w.record(p[:n], err != nil)
+	return n, err
+}
+
+func (w *watchRecorder) Close() error {
+	w.record(nil, true)
+	return w.body.Close()
+}
+
+// record buffers data read from the stream, then records each complete event.
+// If final, any remaining partial event is recorded as well.
+func (w *watchRecorder) record(data []byte, final bool) {
+	offset := duration(time.Since(w.start))
+	w.r.mu.Lock()
+	defer w.r.mu.Unlock()
+	w.buf = append(w.buf, data...)
+	for {
+		n := w.nextEvent()
+		if n == 0 {
+			break
+		}
+		w.resp.Events = append(w.resp.Events, &event{Offset: offset, Data: append(body{}, w.buf[:n]...)})
+		w.buf = w.buf[n:]
+	}
+	if final && len(w.buf) > 0 {
+		w.resp.Events = append(w.resp.Events, &event{Offset: offset, Data: append(body{}, w.buf...)})
+		w.buf = nil
+	}
+}
+
+// nextEvent returns the length of the first complete event in the buffer, or 0
+// if the buffer doesn't contain one.
+func (w *watchRecorder) nextEvent() int {
+	if strings.HasPrefix(w.contentType, contentTypePB) {
+		if len(w.buf) < 4 {
+			return 0
+		}
+		n := 4 + int(binary.BigEndian.Uint32(w.buf))
+		if len(w.buf) < n {
+			return 0
+		}
+		return n
+	}
+	return bytes.IndexByte(w.buf, '\n') + 1
+}
+
+// watchReplayer replays recorded events, waiting until each event's offset
+// before returning it. The stream ends after the last event.
+type watchReplayer struct {
+	ctx    context.Context
+	events []*event
+	start  time.Time
+	buf    []byte
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newWatchReplayer(ctx context.Context, events []*event) *watchReplayer {
+	return &watchReplayer{
+		ctx:    ctx,
+		events: events,
+		start:  time.Now(),
+		closed: make(chan struct{}),
+	}
+}
+
+func (w *watchReplayer) Read(p []byte) (int, error) {
+	for len(w.buf) == 0 {
+		if len(w.events) == 0 {
+			return 0, io.EOF
+		}
+		e := w.events[0]
+		if wait := time.Duration(e.Offset) - time.Since(w.start); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-t.C:
+			case <-w.ctx.Done():
+				t.Stop()
+				return 0, w.ctx.Err()
+			case <-w.closed:
+				t.Stop()
+				return 0, io.ErrClosedPipe
+			}
+		}
+		w.buf = e.Data
+		w.events = w.events[1:]
+	}
+	n := copy(p, w.buf)
+	w.buf = w.buf[n:]
+	return n, nil
+}
+
+func (w *watchReplayer) Close() error {
+	w.closeOnce.Do(func() { close(w.closed) })
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"path"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -30,9 +31,11 @@ type deleteOptions struct {
 
 	GracePeriod   *int64 `json:"gracePeriodSeconds,omitempty"`
 	Preconditions struct {
-		UID string `json:"uid,omitempty"`
+		UID             string `json:"uid,omitempty"`
+		ResourceVersion string `json:"resourceVersion,omitempty"`
 	} `json:"preconditions"`
-	PropagationPolicy string `json:"propagationPolicy"`
+	PropagationPolicy string   `json:"propagationPolicy"`
+	DryRun            []string `json:"dryRun,omitempty"`
 }
 
 // QueryParam can be used to manually set a URL query parameter by name.
@@ -48,12 +51,38 @@ type deleteOptionFunc func(r Resource, d *deleteOptions)
 func (f deleteOptionFunc) updateDelete(r Resource, d *deleteOptions)  { f(r, d) }
 func (f deleteOptionFunc) updateURL(base string, v url.Values) string { return base }
 
+// DeleteAtomic only deletes the resource if its UID matches the UID of the
+// resource passed to Delete. It has no effect on DeleteCollection.
 func DeleteAtomic() Option {
 	return deleteOptionFunc(func(r Resource, d *deleteOptions) {
+		if r == nil {
+			return
+		}
 		d.Preconditions.UID = *r.GetMetadata().Uid
 	})
 }
 
+// DeletePreconditionUID only deletes resources with the given UID. Unlike
+// DeleteAtomic, the UID doesn't have to come from the resource passed to
+// Delete, and the precondition also applies to DeleteCollection.
+func DeletePreconditionUID(uid string) Option {
+	return deleteOptionFunc(func(r Resource, d *deleteOptions) {
+		d.Preconditions.UID = uid
+	})
+}
+
+// DeletePreconditionResourceVersion only deletes resources which haven't been
+// modified since the given resource version, failing with a 409 Conflict
+// otherwise. Preconditions on resource versions require API servers of version
+// 1.16 or later.
+//
+//	err := client.Delete(ctx, cm, k8s.DeletePreconditionResourceVersion(cm.Metadata.GetResourceVersion()))
+func DeletePreconditionResourceVersion(resourceVersion string) Option {
+	return deleteOptionFunc(func(r Resource, d *deleteOptions) {
+		d.Preconditions.ResourceVersion = resourceVersion
+	})
+}
+
 // DeletePropagationOrphan orphans the dependent resources during a delete.
 func DeletePropagationOrphan() Option {
 	return deleteOptionFunc(func(r Resource, d *deleteOptions) {
@@ -87,6 +116,41 @@ func DeleteGracePeriod(d time.Duration) Option {
 	})
 }
 
+type dryRunOption struct{}
+
+func (dryRunOption) updateDelete(r Resource, d *deleteOptions) { d.DryRun = []string{"All"} }
+func (dryRunOption) updateURL(base string, v url.Values) string {
+	v.Set("dryRun", "All")
+	return base
+}
+
+// DryRun causes the API server to validate and admit a create, update, patch
+// or delete without persisting it. Create, Update and Patch decode the result
+// the request would have had into the passed resource.
+//
+//	// validate a deployment without creating it
+//	if err := client.Create(ctx, deployment, k8s.DryRun()); err != nil {
+//		// handle error
+//	}
+//
+// Dry runs require API servers of version 1.13 or later. The option is ignored
+// by gets, lists and watches.
+func DryRun() Option {
+	return dryRunOption{}
+}
+
+// readOptions removes options which only apply to writes, such as DryRun, from
+// the options of a read.
+func readOptions(options []Option) []Option {
+	var filtered []Option
+	for _, option := range options {
+		if _, ok := option.(dryRunOption); !ok {
+			filtered = append(filtered, option)
+		}
+	}
+	return filtered
+}
+
 // ResourceVersion causes watch operations to only show changes since
 // a particular version of a resource.
 func ResourceVersion(resourceVersion string) Option {
@@ -120,11 +184,17 @@ type resourceType struct {
 	apiVersion string
 	name       string
 	namespaced bool
+
+	kind string
 }
 
 var (
 	resources     = map[reflect.Type]resourceType{}
 	resourceLists = map[reflect.Type]resourceType{}
+
+	// kinds and listKinds map kinds to the first type registered for them.
+	kinds     = map[GroupVersionKind]reflect.Type{}
+	listKinds = map[GroupVersionKind]reflect.Type{}
 )
 
 // Resource is a Kubernetes resource, such as a Node or Pod.
@@ -138,12 +208,26 @@ type ResourceList interface {
 	GetMetadata() *metav1.ListMeta
 }
 
+// Register associates a Go type with an API group, version and resource name.
+// The kind of the resource is the name of the Go type.
 func Register(apiGroup, apiVersion, name string, namespaced bool, r Resource) {
+	RegisterKind(apiGroup, apiVersion, name, typeName(reflect.TypeOf(r)), namespaced, r)
+}
+
+// RegisterKind is like Register, but explicitly sets the kind of the resource,
+// for Go types whose name doesn't match their kind.
+func RegisterKind(apiGroup, apiVersion, name, kind string, namespaced bool, r Resource) {
 	rt := reflect.TypeOf(r)
 	if _, ok := resources[rt]; ok {
 		panic(fmt.Sprintf("resource registered twice %T", r))
 	}
-	resources[rt] = resourceType{apiGroup, apiVersion, name, namespaced}
+	t := resourceType{apiGroup, apiVersion, name, namespaced, kind}
+	resources[rt] = t
+
+	gvk := GroupVersionKind{apiGroup, apiVersion, t.kind}
+	if _, ok := kinds[gvk]; !ok {
+		kinds[gvk] = rt
+	}
 }
 
 func RegisterList(apiGroup, apiVersion, name string, namespaced bool, l ResourceList) {
@@ -151,7 +235,110 @@ func RegisterList(apiGroup, apiVersion, name string, namespaced bool, l Resource
 	if _, ok := resources[rt]; ok {
 		panic(fmt.Sprintf("resource registered twice %T", l))
 	}
-	resourceLists[rt] = resourceType{apiGroup, apiVersion, name, namespaced}
+	t := resourceType{apiGroup, apiVersion, name, namespaced, typeName(rt)}
+	resourceLists[rt] = t
+
+	gvk := GroupVersionKind{apiGroup, apiVersion, t.kind}
+	if _, ok := listKinds[gvk]; !ok {
+		listKinds[gvk] = rt
+	}
+}
+
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// NewResource returns a new instance of the type registered for a kind. If
+// multiple types are registered for the same kind, the first is used.
+//
+// For example, NewResource returns a *corev1.ConfigMap for the "v1" "ConfigMap"
+// kind if the core API group package has been imported.
+func NewResource(gvk GroupVersionKind) (Resource, bool) {
+	rt, ok := kinds[gvk]
+	if !ok {
+		return nil, false
+	}
+	return newValue(rt).(Resource), true
+}
+
+// NewResourceList returns a new instance of the list type registered for a kind,
+// such as "PodList".
+func NewResourceList(gvk GroupVersionKind) (ResourceList, bool) {
+	rt, ok := listKinds[gvk]
+	if !ok {
+		return nil, false
+	}
+	return newValue(rt).(ResourceList), true
+}
+
+func newValue(rt reflect.Type) interface{} {
+	if rt.Kind() == reflect.Ptr {
+		return reflect.New(rt.Elem()).Interface()
+	}
+	return reflect.Zero(rt).Interface()
+}
+
+// GroupVersionKindFor returns the kind of a registered type.
+func GroupVersionKindFor(r Resource) (GroupVersionKind, bool) {
+	t, ok := resources[reflect.TypeOf(r)]
+	if !ok {
+		return GroupVersionKind{}, false
+	}
+	return GroupVersionKind{t.apiGroup, t.apiVersion, t.kind}, true
+}
+
+// RESTMappingFor returns the kind and scope of a registered resource. If multiple
+// types are registered for the same resource, the first is used.
+func RESTMappingFor(gvr GroupVersionResource) (*RESTMapping, bool) {
+	var (
+		kind       GroupVersionKind
+		namespaced bool
+		found      bool
+	)
+	for rt, t := range resources {
+		if t.apiGroup != gvr.Group || t.apiVersion != gvr.Version || t.name != gvr.Resource {
+			continue
+		}
+		gvk := GroupVersionKind{t.apiGroup, t.apiVersion, t.kind}
+		kind, namespaced, found = gvk, t.namespaced, true
+		if kinds[gvk] == rt {
+			break
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	return &RESTMapping{Resource: gvr, Kind: kind, Namespaced: namespaced}, true
+}
+
+// RESTMappings returns the mappings of all registered resources, sorted by
+// group, version and resource.
+func RESTMappings() []*RESTMapping {
+	seen := map[GroupVersionResource]bool{}
+	var mappings []*RESTMapping
+	for _, t := range resources {
+		gvr := GroupVersionResource{t.apiGroup, t.apiVersion, t.name}
+		if seen[gvr] {
+			continue
+		}
+		seen[gvr] = true
+		m, _ := RESTMappingFor(gvr)
+		mappings = append(mappings, m)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		a, b := mappings[i].Resource, mappings[j].Resource
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Resource < b.Resource
+	})
+	return mappings
 }
 
 func urlFor(endpoint, apiGroup, apiVersion, namespace, resource, name string, options ...Option) string {
@@ -1,6 +1,10 @@
 package k8s
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -161,3 +165,120 @@ func TestResourceURL(t *testing.T) {
 		})
 	}
 }
+
+type CustomResource struct {
+	TypeMeta
+	Metadata *metav1.ObjectMeta `json:"metadata"`
+}
+
+func (c *CustomResource) GetMetadata() *metav1.ObjectMeta { return c.Metadata }
+
+func init() {
+	RegisterKind("example.com", "v1", "customs", "Custom", true, &CustomResource{})
+}
+
+func TestTypeMetaFor(t *testing.T) {
+	tests := []struct {
+		r    Resource
+		want TypeMeta
+		ok   bool
+	}{
+		{&Pod{}, TypeMeta{"v1", "Pod"}, true},
+		{&Deployment{}, TypeMeta{"apps/v1beta2", "Deployment"}, true},
+		{&CustomResource{}, TypeMeta{"example.com/v1", "Custom"}, true},
+		{&Unstructured{Object: map[string]interface{}{"apiVersion": "example.com/v2", "kind": "Other"}}, TypeMeta{"example.com/v2", "Other"}, true},
+		{&Unstructured{}, TypeMeta{}, false},
+	}
+	for _, test := range tests {
+		got, ok := TypeMetaFor(test.r)
+		if ok != test.ok || got != test.want {
+			t.Errorf("TypeMetaFor(%T): got (%v, %t), want (%v, %t)", test.r, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestSetTypeMeta(t *testing.T) {
+	c := &CustomResource{}
+	if err := SetTypeMeta(c); err != nil {
+		t.Fatal(err)
+	}
+	if want := (TypeMeta{"example.com/v1", "Custom"}); c.TypeMeta != want {
+		t.Errorf("got %v, want %v", c.TypeMeta, want)
+	}
+	if err := SetTypeMeta(&Pod{}); err != nil {
+		t.Errorf("expected no error for type without type meta fields: %v", err)
+	}
+	if err := SetTypeMeta(&Unstructured{}); err == nil {
+		t.Errorf("expected error for unregistered type")
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	data, err := EncodeJSON(&Pod{Metadata: &metav1.ObjectMeta{Name: String("foo")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Metadata":{"name":"foo"},"apiVersion":"v1","kind":"Pod"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestRESTMappingFor(t *testing.T) {
+	m, ok := RESTMappingFor(GroupVersionResource{"apps", "v1beta2", "deployments"})
+	if !ok {
+		t.Fatal("expected registered resource to be found")
+	}
+	want := RESTMapping{
+		Resource:   GroupVersionResource{"apps", "v1beta2", "deployments"},
+		Kind:       GroupVersionKind{"apps", "v1beta2", "Deployment"},
+		Namespaced: true,
+	}
+	if m.Resource != want.Resource || m.Kind != want.Kind || m.Namespaced != want.Namespaced {
+		t.Errorf("got %+v, want %+v", m, want)
+	}
+	if _, ok := RESTMappingFor(GroupVersionResource{"apps", "v1alpha1", "deployments"}); ok {
+		t.Errorf("expected unregistered version to not be found")
+	}
+	if _, ok := NewResourceList(GroupVersionKind{"", "v1", "PodList"}); !ok {
+		t.Errorf("expected registered list kind to be found")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var queries []url.Values
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") != "" {
+			return
+		}
+		w.Write([]byte(`{"metadata":{"name":"my-resource","namespace":"default"}}`))
+	}))
+	defer s.Close()
+
+	c := &Client{Endpoint: s.URL, Client: s.Client()}
+	ctx := context.Background()
+	r := new(CustomResource)
+	if err := c.Get(ctx, "default", "my-resource", r, DryRun()); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	w, err := c.Watch(ctx, "default", new(CustomResource), DryRun())
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	w.Close()
+	if err := c.Update(ctx, r, DryRun()); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	want := []string{"", "", "All"}
+	if len(queries) != len(want) {
+		t.Fatalf("expected %d requests, got %d", len(want), len(queries))
+	}
+	for i, q := range queries {
+		if got := q.Get("dryRun"); got != want[i] {
+			t.Errorf("request %d: expected dryRun=%q, got %q", i, want[i], got)
+		}
+	}
+}
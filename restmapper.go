@@ -0,0 +1,209 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+// GroupVersionKind identifies the kind of an API object, such as "Deployment"
+// in the "apps/v1" group version. The core API group is represented by an empty
+// Group.
+type GroupVersionKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+func (gvk GroupVersionKind) String() string {
+	if gvk.Group == "" {
+		return gvk.Version + ", Kind=" + gvk.Kind
+	}
+	return gvk.Group + "/" + gvk.Version + ", Kind=" + gvk.Kind
+}
+
+// RESTMapping describes how a kind is served by the API server.
+type RESTMapping struct {
+	Resource   GroupVersionResource
+	Kind       GroupVersionKind
+	Namespaced bool
+
+	// Alternative names for the resource, such as "deploy" for "deployments".
+	SingularName string
+	ShortNames   []string
+}
+
+// RESTMapper uses discovery information to map kinds, such as "Deployment", to
+// the resources that serve them, such as "deployments".
+//
+// Discovery information is fetched on first use and cached. If a kind or
+// resource can't be found, the cache is refreshed once before returning an
+// error, so types added after the cache was populated, for example by creating
+// a CustomResourceDefinition, are eventually found.
+//
+//		mapper := k8s.NewRESTMapper(k8s.NewDiscoveryClient(client))
+//		mapping, err := mapper.RESTMapping(ctx, k8s.GroupVersionKind{
+//			Group:   "apps",
+//			Version: "v1",
+//			Kind:    "Deployment",
+//		})
+//		if err != nil {
+//			// handle error
+//		}
+//		fmt.Println(mapping.Resource.Resource, mapping.Namespaced) // "deployments" true
+//
+type RESTMapper struct {
+	discovery *Discovery
+
+	mu    sync.Mutex
+	index []*RESTMapping
+}
+
+// NewRESTMapper returns a mapper backed by a discovery client.
+func NewRESTMapper(d *Discovery) *RESTMapper {
+	return &RESTMapper{discovery: d}
+}
+
+// Reset clears the mapper's cached mappings. Responses cached by the discovery
+// client, if any, aren't affected. Use Discovery.Invalidate to clear those.
+func (m *RESTMapper) Reset() {
+	m.mu.Lock()
+	m.index = nil
+	m.mu.Unlock()
+}
+
+// RESTMapping returns the resource that serves a kind. If the version of gvk is
+// empty, the server's preferred version of the group is used.
+func (m *RESTMapper) RESTMapping(ctx context.Context, gvk GroupVersionKind) (*RESTMapping, error) {
+	return m.lookup(ctx, gvk.String(), func(mapping *RESTMapping) bool {
+		return mapping.Kind.Group == gvk.Group &&
+			mapping.Kind.Kind == gvk.Kind &&
+			(gvk.Version == "" || mapping.Kind.Version == gvk.Version)
+	})
+}
+
+// ResourceFor resolves a resource as it might be typed on a command line, such
+// as "deployments", "deployment", "deploy" or "deployments.apps", returning the
+// mapping for the server's preferred version.
+func (m *RESTMapper) ResourceFor(ctx context.Context, resource string) (*RESTMapping, error) {
+	name, group := strings.ToLower(resource), ""
+	hasGroup := false
+	if i := strings.Index(name, "."); i >= 0 {
+		name, group, hasGroup = name[:i], name[i+1:], true
+	}
+	return m.lookup(ctx, resource, func(mapping *RESTMapping) bool {
+		if hasGroup && mapping.Resource.Group != group {
+			return false
+		}
+		if mapping.Resource.Resource == name ||
+			mapping.SingularName == name ||
+			strings.ToLower(mapping.Kind.Kind) == name {
+			return true
+		}
+		for _, shortName := range mapping.ShortNames {
+			if shortName == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (m *RESTMapper) lookup(ctx context.Context, desc string, match func(m *RESTMapping) bool) (*RESTMapping, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	refreshed := false
+	if m.index == nil {
+		if err := m.load(ctx); err != nil {
+			return nil, err
+		}
+		refreshed = true
+	}
+	for {
+		for _, mapping := range m.index {
+			if match(mapping) {
+				return mapping, nil
+			}
+		}
+		if refreshed {
+			return nil, fmt.Errorf("no matches for %s", desc)
+		}
+		if err := m.discovery.Invalidate(); err != nil {
+			return nil, err
+		}
+		if err := m.load(ctx); err != nil {
+			return nil, err
+		}
+		refreshed = true
+	}
+}
+
+func (m *RESTMapper) load(ctx context.Context) error {
+	lists, err := m.discovery.ServerResources(ctx)
+	if err != nil {
+		// Tolerate broken aggregated API servers, only failing if the
+		// requested type can't be found.
+		if _, ok := err.(*GroupDiscoveryError); !ok {
+			return fmt.Errorf("discover server resources: %v", err)
+		}
+	}
+	m.index = newRESTMappings(lists)
+	return nil
+}
+
+// preferredVersionOrder returns the versions of a group, starting with the
+// preferred version.
+func preferredVersionOrder(group *metav1.APIGroup) []string {
+	preferred := group.GetPreferredVersion().GetVersion()
+	var versions []string
+	if preferred != "" {
+		versions = append(versions, preferred)
+	}
+	for _, v := range group.Versions {
+		if v.GetVersion() != preferred {
+			versions = append(versions, v.GetVersion())
+		}
+	}
+	return versions
+}
+
+// newRESTMappings flattens resource lists into mappings, preserving the order of
+// the lists. Subresources are ignored.
+func newRESTMappings(lists []*metav1.APIResourceList) []*RESTMapping {
+	var mappings []*RESTMapping
+	for _, list := range lists {
+		group, version := parseGroupVersion(list.GetGroupVersion())
+		for _, r := range list.Resources {
+			if strings.Contains(r.GetName(), "/") {
+				continue
+			}
+			kindGroup, kindVersion := group, version
+			if r.Group != nil {
+				kindGroup = r.GetGroup()
+			}
+			if r.Version != nil {
+				kindVersion = r.GetVersion()
+			}
+			mappings = append(mappings, &RESTMapping{
+				Resource:     GroupVersionResource{group, version, r.GetName()},
+				Kind:         GroupVersionKind{kindGroup, kindVersion, r.GetKind()},
+				Namespaced:   r.GetNamespaced(),
+				SingularName: r.GetSingularName(),
+				ShortNames:   r.ShortNames,
+			})
+		}
+	}
+	return mappings
+}
+
+// parseGroupVersion splits a group version such as "apps/v1" or "v1".
+func parseGroupVersion(gv string) (group, version string) {
+	if i := strings.LastIndex(gv, "/"); i >= 0 {
+		return gv[:i], gv[i+1:]
+	}
+	return "", gv
+}
@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+func TestRESTMapper(t *testing.T) {
+	lists := []*metav1.APIResourceList{
+		{
+			GroupVersion: String("v1"),
+			Resources: []*metav1.APIResource{
+				{Name: String("pods"), SingularName: String("pod"), Kind: String("Pod"), Namespaced: Bool(true), ShortNames: []string{"po"}},
+				{Name: String("pods/status"), Kind: String("Pod"), Namespaced: Bool(true)},
+				{Name: String("nodes"), SingularName: String("node"), Kind: String("Node"), ShortNames: []string{"no"}},
+			},
+		},
+		{
+			GroupVersion: String("apps/v1"),
+			Resources: []*metav1.APIResource{
+				{Name: String("deployments"), SingularName: String("deployment"), Kind: String("Deployment"), Namespaced: Bool(true), ShortNames: []string{"deploy"}},
+				{Name: String("deployments/scale"), Group: String("autoscaling"), Version: String("v1"), Kind: String("Scale"), Namespaced: Bool(true)},
+			},
+		},
+		{
+			GroupVersion: String("apps/v1beta2"),
+			Resources: []*metav1.APIResource{
+				{Name: String("deployments"), SingularName: String("deployment"), Kind: String("Deployment"), Namespaced: Bool(true), ShortNames: []string{"deploy"}},
+			},
+		},
+		{
+			GroupVersion: String("example.com/v1"),
+			Resources: []*metav1.APIResource{
+				// Older servers don't populate singular names for custom resources.
+				{Name: String("widgets"), Kind: String("Widget")},
+			},
+		},
+	}
+	m := &RESTMapper{index: newRESTMappings(lists)}
+
+	mappingTests := []struct {
+		gvk  GroupVersionKind
+		want GroupVersionResource
+	}{
+		{GroupVersionKind{"", "v1", "Pod"}, GroupVersionResource{"", "v1", "pods"}},
+		{GroupVersionKind{"apps", "", "Deployment"}, GroupVersionResource{"apps", "v1", "deployments"}},
+		{GroupVersionKind{"apps", "v1beta2", "Deployment"}, GroupVersionResource{"apps", "v1beta2", "deployments"}},
+		{GroupVersionKind{"example.com", "v1", "Widget"}, GroupVersionResource{"example.com", "v1", "widgets"}},
+	}
+	for _, test := range mappingTests {
+		got, err := m.RESTMapping(context.Background(), test.gvk)
+		if err != nil {
+			t.Errorf("mapping %s: %v", test.gvk, err)
+			continue
+		}
+		if got.Resource != test.want {
+			t.Errorf("mapping %s: wanted=%s, got=%s", test.gvk, test.want, got.Resource)
+		}
+	}
+
+	resourceTests := []struct {
+		resource       string
+		want           GroupVersionResource
+		wantNamespaced bool
+	}{
+		{"pods", GroupVersionResource{"", "v1", "pods"}, true},
+		{"po", GroupVersionResource{"", "v1", "pods"}, true},
+		{"Node", GroupVersionResource{"", "v1", "nodes"}, false},
+		{"deploy", GroupVersionResource{"apps", "v1", "deployments"}, true},
+		{"deployments.apps", GroupVersionResource{"apps", "v1", "deployments"}, true},
+		{"widget", GroupVersionResource{"example.com", "v1", "widgets"}, false},
+		{"widgets.example.com", GroupVersionResource{"example.com", "v1", "widgets"}, false},
+	}
+	for _, test := range resourceTests {
+		got, err := m.ResourceFor(context.Background(), test.resource)
+		if err != nil {
+			t.Errorf("resource %s: %v", test.resource, err)
+			continue
+		}
+		if got.Resource != test.want {
+			t.Errorf("resource %s: wanted=%s, got=%s", test.resource, test.want, got.Resource)
+		}
+		if got.Namespaced != test.wantNamespaced {
+			t.Errorf("resource %s: wanted namespaced=%t, got=%t", test.resource, test.wantNamespaced, got.Namespaced)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ericchiang/k8s"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/golang/protobuf/proto"
+)
+
+// Revision is a revision of the pod template of a Deployment, StatefulSet or
+// DaemonSet.
+type Revision struct {
+	// Revision number, which increases with each change to the pod template.
+	Revision int64
+
+	// ChangeCause is the "kubernetes.io/change-cause" annotation of the
+	// revision, if set.
+	ChangeCause string
+
+	// Object recording the revision. A *appsv1.ReplicaSet for Deployments, and
+	// a *appsv1.ControllerRevision for StatefulSets and DaemonSets.
+	Object k8s.Resource
+}
+
+// History returns the revisions of a Deployment, StatefulSet or DaemonSet,
+// ordered from oldest to newest. Revisions are recorded by the ReplicaSets or
+// ControllerRevisions owned by the resource, so only revisions within the
+// resource's revision history limit are returned.
+func History(ctx context.Context, c k8s.Reader, r k8s.Resource) ([]Revision, error) {
+	meta := r.GetMetadata()
+	var (
+		revisions []Revision
+		selector  *metav1.LabelSelector
+	)
+	switch r := r.(type) {
+	case *appsv1.Deployment:
+		selector = r.GetSpec().GetSelector()
+	case *appsv1.StatefulSet:
+		selector = r.GetSpec().GetSelector()
+	case *appsv1.DaemonSet:
+		selector = r.GetSpec().GetSelector()
+	default:
+		return nil, fmt.Errorf("rollout: unsupported type %T", r)
+	}
+	var options []k8s.Option
+	if labels := selector.GetMatchLabels(); len(labels) > 0 {
+		l := new(k8s.LabelSelector)
+		for k, v := range labels {
+			l.Eq(k, v)
+		}
+		options = append(options, l.Selector())
+	}
+
+	if _, ok := r.(*appsv1.Deployment); ok {
+		var list appsv1.ReplicaSetList
+		if err := c.List(ctx, meta.GetNamespace(), &list, options...); err != nil {
+			return nil, fmt.Errorf("list replica sets: %v", err)
+		}
+		for _, rs := range list.Items {
+			if !controlledBy(rs.GetMetadata(), meta) {
+				continue
+			}
+			annotations := rs.GetMetadata().GetAnnotations()
+			revision, err := strconv.ParseInt(annotations[revisionAnnotation], 10, 64)
+			if err != nil {
+				// Not yet annotated by the deployment controller.
+				continue
+			}
+			revisions = append(revisions, Revision{
+				Revision:    revision,
+				ChangeCause: annotations[changeCauseAnnotation],
+				Object:      rs,
+			})
+		}
+	} else {
+		var list appsv1.ControllerRevisionList
+		if err := c.List(ctx, meta.GetNamespace(), &list, options...); err != nil {
+			return nil, fmt.Errorf("list controller revisions: %v", err)
+		}
+		for _, cr := range list.Items {
+			if !controlledBy(cr.GetMetadata(), meta) {
+				continue
+			}
+			revisions = append(revisions, Revision{
+				Revision:    cr.GetRevision(),
+				ChangeCause: cr.GetMetadata().GetAnnotations()[changeCauseAnnotation],
+				Object:      cr,
+			})
+		}
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// controlledBy reports if an object's controller is the given owner.
+func controlledBy(obj, owner *metav1.ObjectMeta) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.GetController() && ref.GetUid() == owner.GetUid() {
+			return true
+		}
+	}
+	return false
+}
+
+// Rollback rolls a Deployment, StatefulSet or DaemonSet back to the pod template
+// of an earlier revision, like "kubectl rollout undo". A revision of zero rolls
+// back to the previous revision. Rolling back to the current revision does
+// nothing. r is updated with the response of the API server.
+func Rollback(ctx context.Context, c Client, r k8s.Resource, revision int64) error {
+	revisions, err := History(ctx, c, r)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		return errors.New("no rollout history found")
+	}
+	current := revisions[len(revisions)-1]
+
+	var target *Revision
+	if revision == 0 {
+		if len(revisions) < 2 {
+			return errors.New("no previous revision to roll back to")
+		}
+		target = &revisions[len(revisions)-2]
+	} else {
+		for i := range revisions {
+			if revisions[i].Revision == revision {
+				target = &revisions[i]
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("unable to find revision %d", revision)
+		}
+	}
+	if target.Revision == current.Revision {
+		return nil
+	}
+
+	switch obj := target.Object.(type) {
+	case *appsv1.ReplicaSet:
+		return rollbackDeployment(ctx, c, r.(*appsv1.Deployment), obj)
+	case *appsv1.ControllerRevision:
+		if err := c.Patch(ctx, r, k8s.PatchStrategicMerge, obj.GetData().GetRaw()); err != nil {
+			return fmt.Errorf("roll back %s to revision %d: %v", r.GetMetadata().GetName(), target.Revision, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("rollout: unexpected revision type %T", obj)
+	}
+}
+
+// rollbackDeployment replaces the pod template of a Deployment with the pod
+// template of a ReplicaSet. The deployment controller then scales up the
+// ReplicaSet and gives it a new revision.
+func rollbackDeployment(ctx context.Context, c Client, d *appsv1.Deployment, rs *appsv1.ReplicaSet) error {
+	meta := d.GetMetadata()
+	latest := new(appsv1.Deployment)
+	if err := c.Get(ctx, meta.GetNamespace(), meta.GetName(), latest); err != nil {
+		return fmt.Errorf("get deployment %s: %v", meta.GetName(), err)
+	}
+	if latest.GetSpec().GetPaused() {
+		return fmt.Errorf("cannot roll back paused deployment %s", meta.GetName())
+	}
+
+	template := proto.Clone(rs.GetSpec().GetTemplate()).(*corev1.PodTemplateSpec)
+	if template.Metadata != nil {
+		// Added to pod templates by the deployment controller.
+		delete(template.Metadata.Labels, "pod-template-hash")
+	}
+	latest.Spec.Template = template
+	if cause, ok := rs.GetMetadata().GetAnnotations()[changeCauseAnnotation]; ok {
+		if latest.Metadata.Annotations == nil {
+			latest.Metadata.Annotations = map[string]string{}
+		}
+		latest.Metadata.Annotations[changeCauseAnnotation] = cause
+	}
+	if err := c.Update(ctx, latest); err != nil {
+		return fmt.Errorf("roll back deployment %s: %v", meta.GetName(), err)
+	}
+	*d = *latest
+	return nil
+}
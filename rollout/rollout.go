@@ -0,0 +1,187 @@
+/*
+Package rollout reports the progress of rollouts of apps/v1 Deployments,
+StatefulSets and DaemonSets, and implements rollbacks and restarts, similar to
+"kubectl rollout".
+
+	var deployment appsv1.Deployment
+	if err := client.Get(ctx, "my-namespace", "my-deployment", &deployment); err != nil {
+		// handle error
+	}
+	if err := rollout.Restart(ctx, client, &deployment); err != nil {
+		// handle error
+	}
+	if err := wait.For(ctx, client, &deployment, rollout.Complete, 10*time.Minute); err != nil {
+		// handle error
+	}
+
+StatefulSets and DaemonSets only report rollout status for the RollingUpdate
+update strategy.
+*/
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+)
+
+// Annotations used by the deployment controller and kubectl.
+const (
+	// revisionAnnotation holds the revision of a Deployment and its ReplicaSets.
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+	// changeCauseAnnotation records the reason for a change.
+	changeCauseAnnotation = "kubernetes.io/change-cause"
+	// restartedAtAnnotation is set on pod templates to restart workloads.
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+)
+
+// Client is the subset of k8s.Interface used for rollouts. It's implemented
+// by *k8s.Client.
+type Client interface {
+	k8s.Reader
+	k8s.Writer
+}
+
+// Status is the progress of a rollout.
+type Status struct {
+	// Done is true once the rollout has completed.
+	Done bool
+	// Message describes the progress of the rollout, in the same terms as
+	// "kubectl rollout status".
+	Message string
+}
+
+// StatusOf returns the rollout progress of a Deployment, StatefulSet or
+// DaemonSet. An error is returned if the rollout has failed, such as a
+// Deployment exceeding its progress deadline.
+func StatusOf(r k8s.Resource) (*Status, error) {
+	switch r := r.(type) {
+	case *appsv1.Deployment:
+		return deploymentStatus(r)
+	case *appsv1.StatefulSet:
+		return statefulSetStatus(r)
+	case *appsv1.DaemonSet:
+		return daemonSetStatus(r)
+	default:
+		return nil, fmt.Errorf("rollout: unsupported type %T", r)
+	}
+}
+
+// Complete is a wait.Predicate satisfied once a rollout has completed.
+func Complete(r k8s.Resource) (bool, error) {
+	s, err := StatusOf(r)
+	if err != nil {
+		return false, err
+	}
+	return s.Done, nil
+}
+
+func inProgress(format string, v ...interface{}) (*Status, error) {
+	return &Status{Message: fmt.Sprintf(format, v...)}, nil
+}
+
+func done(format string, v ...interface{}) (*Status, error) {
+	return &Status{Done: true, Message: fmt.Sprintf(format, v...)}, nil
+}
+
+func deploymentStatus(d *appsv1.Deployment) (*Status, error) {
+	name := d.GetMetadata().GetName()
+	status := d.GetStatus()
+	if d.GetMetadata().GetGeneration() > status.GetObservedGeneration() {
+		return inProgress("Waiting for deployment spec update to be observed...")
+	}
+	for _, c := range status.GetConditions() {
+		if c.GetType() == "Progressing" && c.GetReason() == "ProgressDeadlineExceeded" {
+			return nil, fmt.Errorf("deployment %q exceeded its progress deadline", name)
+		}
+	}
+	updated := status.GetUpdatedReplicas()
+	if replicas := d.GetSpec().Replicas; replicas != nil && updated < *replicas {
+		return inProgress("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...", name, updated, *replicas)
+	}
+	if status.GetReplicas() > updated {
+		return inProgress("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...", name, status.GetReplicas()-updated)
+	}
+	if available := status.GetAvailableReplicas(); available < updated {
+		return inProgress("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...", name, available, updated)
+	}
+	return done("deployment %q successfully rolled out", name)
+}
+
+func statefulSetStatus(s *appsv1.StatefulSet) (*Status, error) {
+	strategy := s.GetSpec().GetUpdateStrategy()
+	if t := strategy.GetType(); t != "" && t != "RollingUpdate" {
+		return nil, fmt.Errorf("rollout status is only available for RollingUpdate strategy type")
+	}
+	status := s.GetStatus()
+	if status.GetObservedGeneration() == 0 || s.GetMetadata().GetGeneration() > status.GetObservedGeneration() {
+		return inProgress("Waiting for statefulset spec update to be observed...")
+	}
+	replicas := s.GetSpec().Replicas
+	if replicas != nil && status.GetReadyReplicas() < *replicas {
+		return inProgress("Waiting for %d pods to be ready...", *replicas-status.GetReadyReplicas())
+	}
+	if partition := strategy.GetRollingUpdate().GetPartition(); replicas != nil && partition > 0 {
+		if status.GetUpdatedReplicas() < *replicas-partition {
+			return inProgress("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...", status.GetUpdatedReplicas(), *replicas-partition)
+		}
+		return done("partitioned roll out complete: %d new pods have been updated...", status.GetUpdatedReplicas())
+	}
+	if status.GetUpdateRevision() != status.GetCurrentRevision() {
+		return inProgress("waiting for statefulset rolling update to complete %d pods at revision %s...", status.GetUpdatedReplicas(), status.GetUpdateRevision())
+	}
+	return done("statefulset rolling update complete %d pods at revision %s...", status.GetCurrentReplicas(), status.GetCurrentRevision())
+}
+
+func daemonSetStatus(d *appsv1.DaemonSet) (*Status, error) {
+	if t := d.GetSpec().GetUpdateStrategy().GetType(); t != "" && t != "RollingUpdate" {
+		return nil, fmt.Errorf("rollout status is only available for RollingUpdate strategy type")
+	}
+	name := d.GetMetadata().GetName()
+	status := d.GetStatus()
+	if d.GetMetadata().GetGeneration() > status.GetObservedGeneration() {
+		return inProgress("Waiting for daemon set spec update to be observed...")
+	}
+	desired := status.GetDesiredNumberScheduled()
+	if updated := status.GetUpdatedNumberScheduled(); updated < desired {
+		return inProgress("Waiting for daemon set %q rollout to finish: %d out of %d new pods have been updated...", name, updated, desired)
+	}
+	if available := status.GetNumberAvailable(); available < desired {
+		return inProgress("Waiting for daemon set %q rollout to finish: %d of %d updated pods are available...", name, available, desired)
+	}
+	return done("daemon set %q successfully rolled out", name)
+}
+
+// Restart restarts the pods of a Deployment, StatefulSet or DaemonSet by
+// setting an annotation on its pod template to the current time, like
+// "kubectl rollout restart". r is updated with the response of the API server.
+func Restart(ctx context.Context, c Client, r k8s.Resource) error {
+	switch r.(type) {
+	case *appsv1.Deployment, *appsv1.StatefulSet, *appsv1.DaemonSet:
+	default:
+		return fmt.Errorf("rollout: unsupported type %T", r)
+	}
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						restartedAtAnnotation: time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	if err := c.Patch(ctx, r, k8s.PatchStrategicMerge, data); err != nil {
+		return fmt.Errorf("restart %s: %v", r.GetMetadata().GetName(), err)
+	}
+	return nil
+}
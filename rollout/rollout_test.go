@@ -0,0 +1,341 @@
+package rollout
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/fake"
+	"github.com/ericchiang/k8s/runtime"
+)
+
+func int32p(i int32) *int32 { return &i }
+func int64p(i int64) *int64 { return &i }
+
+func TestStatusOf(t *testing.T) {
+	deployment := func(generation int64, status *appsv1.DeploymentStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			Metadata: &metav1.ObjectMeta{Name: k8s.String("web"), Generation: &generation},
+			Spec:     &appsv1.DeploymentSpec{Replicas: int32p(3)},
+			Status:   status,
+		}
+	}
+	statefulSet := func(partition int32, status *appsv1.StatefulSetStatus) *appsv1.StatefulSet {
+		s := &appsv1.StatefulSet{
+			Metadata: &metav1.ObjectMeta{Name: k8s.String("db"), Generation: int64p(1)},
+			Spec: &appsv1.StatefulSetSpec{
+				Replicas:       int32p(3),
+				UpdateStrategy: &appsv1.StatefulSetUpdateStrategy{Type: k8s.String("RollingUpdate")},
+			},
+			Status: status,
+		}
+		if partition > 0 {
+			s.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+		}
+		return s
+	}
+	daemonSet := func(strategy string, status *appsv1.DaemonSetStatus) *appsv1.DaemonSet {
+		return &appsv1.DaemonSet{
+			Metadata: &metav1.ObjectMeta{Name: k8s.String("agent"), Generation: int64p(1)},
+			Spec: &appsv1.DaemonSetSpec{
+				UpdateStrategy: &appsv1.DaemonSetUpdateStrategy{Type: k8s.String(strategy)},
+			},
+			Status: status,
+		}
+	}
+
+	tests := []struct {
+		r       k8s.Resource
+		done    bool
+		message string
+		wantErr bool
+	}{
+		{
+			r:       deployment(2, &appsv1.DeploymentStatus{ObservedGeneration: int64p(1)}),
+			message: "Waiting for deployment spec update to be observed...",
+		},
+		{
+			r: deployment(2, &appsv1.DeploymentStatus{
+				ObservedGeneration: int64p(2),
+				Replicas:           int32p(4),
+				UpdatedReplicas:    int32p(1),
+			}),
+			message: `Waiting for deployment "web" rollout to finish: 1 out of 3 new replicas have been updated...`,
+		},
+		{
+			r: deployment(2, &appsv1.DeploymentStatus{
+				ObservedGeneration: int64p(2),
+				Replicas:           int32p(4),
+				UpdatedReplicas:    int32p(3),
+			}),
+			message: `Waiting for deployment "web" rollout to finish: 1 old replicas are pending termination...`,
+		},
+		{
+			r: deployment(2, &appsv1.DeploymentStatus{
+				ObservedGeneration: int64p(2),
+				Replicas:           int32p(3),
+				UpdatedReplicas:    int32p(3),
+				AvailableReplicas:  int32p(2),
+			}),
+			message: `Waiting for deployment "web" rollout to finish: 2 of 3 updated replicas are available...`,
+		},
+		{
+			r: deployment(2, &appsv1.DeploymentStatus{
+				ObservedGeneration: int64p(2),
+				Replicas:           int32p(3),
+				UpdatedReplicas:    int32p(3),
+				AvailableReplicas:  int32p(3),
+			}),
+			done:    true,
+			message: `deployment "web" successfully rolled out`,
+		},
+		{
+			r: deployment(2, &appsv1.DeploymentStatus{
+				ObservedGeneration: int64p(2),
+				Conditions: []*appsv1.DeploymentCondition{{
+					Type:   k8s.String("Progressing"),
+					Status: k8s.String("False"),
+					Reason: k8s.String("ProgressDeadlineExceeded"),
+				}},
+			}),
+			wantErr: true,
+		},
+		{
+			r: statefulSet(0, &appsv1.StatefulSetStatus{
+				ObservedGeneration: int64p(1),
+				ReadyReplicas:      int32p(1),
+			}),
+			message: "Waiting for 2 pods to be ready...",
+		},
+		{
+			r: statefulSet(0, &appsv1.StatefulSetStatus{
+				ObservedGeneration: int64p(1),
+				ReadyReplicas:      int32p(3),
+				UpdatedReplicas:    int32p(1),
+				CurrentRevision:    k8s.String("db-1"),
+				UpdateRevision:     k8s.String("db-2"),
+			}),
+			message: "waiting for statefulset rolling update to complete 1 pods at revision db-2...",
+		},
+		{
+			r: statefulSet(0, &appsv1.StatefulSetStatus{
+				ObservedGeneration: int64p(1),
+				ReadyReplicas:      int32p(3),
+				CurrentReplicas:    int32p(3),
+				CurrentRevision:    k8s.String("db-2"),
+				UpdateRevision:     k8s.String("db-2"),
+			}),
+			done:    true,
+			message: "statefulset rolling update complete 3 pods at revision db-2...",
+		},
+		{
+			r: statefulSet(2, &appsv1.StatefulSetStatus{
+				ObservedGeneration: int64p(1),
+				ReadyReplicas:      int32p(3),
+				UpdatedReplicas:    int32p(1),
+			}),
+			done:    true,
+			message: "partitioned roll out complete: 1 new pods have been updated...",
+		},
+		{
+			r: daemonSet("RollingUpdate", &appsv1.DaemonSetStatus{
+				ObservedGeneration:     int64p(1),
+				DesiredNumberScheduled: int32p(5),
+				UpdatedNumberScheduled: int32p(5),
+				NumberAvailable:        int32p(4),
+			}),
+			message: `Waiting for daemon set "agent" rollout to finish: 4 of 5 updated pods are available...`,
+		},
+		{
+			r: daemonSet("RollingUpdate", &appsv1.DaemonSetStatus{
+				ObservedGeneration:     int64p(1),
+				DesiredNumberScheduled: int32p(5),
+				UpdatedNumberScheduled: int32p(5),
+				NumberAvailable:        int32p(5),
+			}),
+			done:    true,
+			message: `daemon set "agent" successfully rolled out`,
+		},
+		{
+			r:       daemonSet("OnDelete", &appsv1.DaemonSetStatus{}),
+			wantErr: true,
+		},
+		{
+			r:       &corev1.Pod{},
+			wantErr: true,
+		},
+	}
+	for i, test := range tests {
+		s, err := StatusOf(test.r)
+		if err != nil {
+			if !test.wantErr {
+				t.Errorf("case %d: %v", i, err)
+			}
+			continue
+		}
+		if test.wantErr {
+			t.Errorf("case %d: expected error, got %+v", i, s)
+			continue
+		}
+		if s.Done != test.done || s.Message != test.message {
+			t.Errorf("case %d: got %+v, want done=%v message=%q", i, s, test.done, test.message)
+		}
+	}
+}
+
+func podTemplate(image string) *corev1.PodTemplateSpec {
+	return &corev1.PodTemplateSpec{
+		Metadata: &metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+		Spec: &corev1.PodSpec{
+			Containers: []*corev1.Container{{Name: k8s.String("web"), Image: k8s.String(image)}},
+		},
+	}
+}
+
+func ownerReference(kind string, meta *metav1.ObjectMeta) []*metav1.OwnerReference {
+	return []*metav1.OwnerReference{{
+		ApiVersion: k8s.String("apps/v1"),
+		Kind:       k8s.String(kind),
+		Name:       meta.Name,
+		Uid:        meta.Uid,
+		Controller: k8s.Bool(true),
+	}}
+}
+
+func TestDeploymentRollback(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	d := &appsv1.Deployment{
+		Metadata: &metav1.ObjectMeta{Name: k8s.String("web"), Namespace: k8s.String("default")},
+		Spec: &appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: podTemplate("web:3"),
+		},
+	}
+	if err := client.Create(ctx, d); err != nil {
+		t.Fatalf("create deployment: %v", err)
+	}
+	for i, image := range []string{"web:1", "web:2", "web:3"} {
+		template := podTemplate(image)
+		template.Metadata.Labels["pod-template-hash"] = image[4:]
+		rs := &appsv1.ReplicaSet{
+			Metadata: &metav1.ObjectMeta{
+				Name:            k8s.String("web-" + image[4:]),
+				Namespace:       k8s.String("default"),
+				Labels:          map[string]string{"app": "web"},
+				Annotations:     map[string]string{revisionAnnotation: string('1' + rune(i))},
+				OwnerReferences: ownerReference("Deployment", d.Metadata),
+			},
+			Spec: &appsv1.ReplicaSetSpec{Template: template},
+		}
+		if err := client.Create(ctx, rs); err != nil {
+			t.Fatalf("create replica set: %v", err)
+		}
+	}
+	// Not owned by the deployment.
+	other := &appsv1.ReplicaSet{
+		Metadata: &metav1.ObjectMeta{
+			Name:        k8s.String("other"),
+			Namespace:   k8s.String("default"),
+			Labels:      map[string]string{"app": "web"},
+			Annotations: map[string]string{revisionAnnotation: "7"},
+		},
+	}
+	if err := client.Create(ctx, other); err != nil {
+		t.Fatalf("create replica set: %v", err)
+	}
+
+	revisions, err := History(ctx, client, d)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	var got []string
+	for _, r := range revisions {
+		got = append(got, r.Object.GetMetadata().GetName())
+	}
+	if strings.Join(got, ",") != "web-1,web-2,web-3" {
+		t.Errorf("unexpected history %q", got)
+	}
+
+	if err := Rollback(ctx, client, d, 0); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	template := d.GetSpec().GetTemplate()
+	if image := template.GetSpec().GetContainers()[0].GetImage(); image != "web:2" {
+		t.Errorf("expected rollback to previous revision, got image %s", image)
+	}
+	if _, ok := template.GetMetadata().GetLabels()["pod-template-hash"]; ok {
+		t.Errorf("expected pod-template-hash label to be removed")
+	}
+
+	if err := Rollback(ctx, client, d, 1); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if image := d.GetSpec().GetTemplate().GetSpec().GetContainers()[0].GetImage(); image != "web:1" {
+		t.Errorf("expected rollback to revision 1, got image %s", image)
+	}
+	if err := Rollback(ctx, client, d, 5); err == nil {
+		t.Errorf("expected error rolling back to missing revision")
+	}
+}
+
+func TestDaemonSetRollbackAndRestart(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	ds := &appsv1.DaemonSet{
+		Metadata: &metav1.ObjectMeta{Name: k8s.String("web"), Namespace: k8s.String("default")},
+		Spec: &appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: podTemplate("web:2"),
+		},
+	}
+	if err := client.Create(ctx, ds); err != nil {
+		t.Fatalf("create daemon set: %v", err)
+	}
+	revisions := map[int64]string{
+		1: `{"spec":{"template":{"metadata":{"labels":{"app":"web"}},"spec":{"containers":[{"name":"web","image":"web:1"}]},"$patch":"replace"}}}`,
+		2: `{"spec":{"template":{"metadata":{"labels":{"app":"web"}},"spec":{"containers":[{"name":"web","image":"web:2"}]},"$patch":"replace"}}}`,
+	}
+	for revision, data := range revisions {
+		cr := &appsv1.ControllerRevision{
+			Metadata: &metav1.ObjectMeta{
+				Name:            k8s.String("web-" + string('0'+rune(revision))),
+				Namespace:       k8s.String("default"),
+				Labels:          map[string]string{"app": "web"},
+				OwnerReferences: ownerReference("DaemonSet", ds.Metadata),
+			},
+			Data:     &runtime.RawExtension{Raw: []byte(data)},
+			Revision: int64p(revision),
+		}
+		if err := client.Create(ctx, cr); err != nil {
+			t.Fatalf("create controller revision: %v", err)
+		}
+	}
+
+	if err := Rollback(ctx, client, ds, 0); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if image := ds.GetSpec().GetTemplate().GetSpec().GetContainers()[0].GetImage(); image != "web:1" {
+		t.Errorf("expected rollback to revision 1, got image %s", image)
+	}
+
+	if err := Restart(ctx, client, ds); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if _, ok := ds.GetSpec().GetTemplate().GetMetadata().GetAnnotations()[restartedAtAnnotation]; !ok {
+		t.Errorf("expected restart annotation to be set")
+	}
+	if image := ds.GetSpec().GetTemplate().GetSpec().GetContainers()[0].GetImage(); image != "web:1" {
+		t.Errorf("restart changed the pod template, got image %s", image)
+	}
+}
@@ -0,0 +1,20 @@
+package runtime
+
+// JSON marshaling logic for the RawExtension type, which holds an embedded
+// object. The object is encoded inline rather than as a base64 string.
+
+func (re RawExtension) MarshalJSON() ([]byte, error) {
+	if re.Raw == nil {
+		return []byte("null"), nil
+	}
+	return re.Raw, nil
+}
+
+func (re *RawExtension) UnmarshalJSON(p []byte) error {
+	if string(p) == "null" {
+		re.Raw = nil
+		return nil
+	}
+	re.Raw = append(re.Raw[:0], p...)
+	return nil
+}
@@ -0,0 +1,10 @@
+#!/bin/bash -e
+
+# Generated packages are replaced wholesale by generate.sh. Hand-written files
+# in those packages, such as JSON marshaling logic, are kept under
+# scripts/partials at the same path with a .partial suffix, and copied back.
+
+cd scripts/partials
+for FILE in $( find . -type f -name '*.partial' ); do
+    cp $FILE ../../${FILE%.partial}
+done
@@ -0,0 +1,104 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"time"
+)
+
+// UpdateStatus updates the "status" subresource of a resource. Only the status
+// of the object is persisted, changes to the spec or metadata are ignored by the
+// API server. The result is unmarshaled into r.
+//
+// UpdateStatus uses the discovery API to verify that the resource type supports
+// a status subresource, and returns an error if it doesn't, rather than falling
+// back to a full update. Discovery information is cached by the client, and
+// refreshed before reporting that a resource has no status subresource, so
+// subresources added by updating a CustomResourceDefinition are found. The
+// refresh is done at most once every ten minutes for each group version.
+//
+//		deployment.Status.ObservedGeneration = deployment.Metadata.Generation
+//		if err := client.UpdateStatus(ctx, deployment); err != nil {
+//			// handle error
+//		}
+//
+func (c *Client) UpdateStatus(ctx context.Context, r Resource, options ...Option) error {
+	if err := c.checkStatusSubresource(ctx, r); err != nil {
+		return err
+	}
+	url, err := resourceURL(c.Endpoint, r, true, append(options[:len(options):len(options)], Subresource("status"))...)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, "PUT", url, r, r, options...)
+}
+
+// PatchStatus applies a patch document to the "status" subresource of a resource.
+// Like UpdateStatus, it returns an error if the resource type doesn't support a
+// status subresource. The result is unmarshaled into r.
+func (c *Client) PatchStatus(ctx context.Context, r Resource, pt PatchType, data []byte, options ...Option) error {
+	if err := c.checkStatusSubresource(ctx, r); err != nil {
+		return err
+	}
+	return c.Patch(ctx, r, pt, data, append(options[:len(options):len(options)], Subresource("status"))...)
+}
+
+// discoveryTTL is how long discovery responses used internally by the client
+// are cached before being revalidated with the API server.
+const discoveryTTL = 10 * time.Minute
+
+// discovery returns the client's cached discovery client.
+func (c *Client) discovery() *Discovery {
+	if d, ok := c.cachedDiscovery.Load().(*Discovery); ok {
+		return d
+	}
+	// Concurrent callers may both create a discovery client, only one is kept.
+	c.cachedDiscovery.CompareAndSwap(nil, NewCachedDiscoveryClient(c, "", discoveryTTL))
+	return c.cachedDiscovery.Load().(*Discovery)
+}
+
+func (c *Client) checkStatusSubresource(ctx context.Context, r Resource) error {
+	t, ok := resources[reflect.TypeOf(r)]
+	if !ok {
+		return fmt.Errorf("unregistered type %T", r)
+	}
+	ok, err := c.hasStatusSubresource(ctx, t)
+	if err != nil {
+		return fmt.Errorf("discovering status subresource: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("resource %q in %q does not have a status subresource",
+			t.name, path.Join(t.apiGroup, t.apiVersion))
+	}
+	return nil
+}
+
+func (c *Client) hasStatusSubresource(ctx context.Context, t resourceType) (bool, error) {
+	d := c.discovery()
+	ok, err := hasSubresource(ctx, d, t, "status")
+	if err != nil || ok {
+		return ok, err
+	}
+	// The cached discovery information may predate the subresource, for
+	// example if a CustomResourceDefinition was updated to enable it. Refresh
+	// it before failing, unless that was recently done.
+	if !d.refreshAPIResources(t.apiGroup, t.apiVersion) {
+		return false, nil
+	}
+	return hasSubresource(ctx, d, t, "status")
+}
+
+func hasSubresource(ctx context.Context, d *Discovery, t resourceType, subresource string) (bool, error) {
+	list, err := d.APIResources(ctx, t.apiGroup, t.apiVersion)
+	if err != nil {
+		return false, err
+	}
+	for _, resource := range list.Resources {
+		if resource.GetName() == t.name+"/"+subresource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
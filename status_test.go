@@ -0,0 +1,98 @@
+package k8s_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+func TestUpdateStatusRefreshesDiscovery(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		hasStatus bool
+		discovery int
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/apis/apps/v1":
+			discovery++
+			resources := `{"name":"deployments","namespaced":true,"kind":"Deployment"},` +
+				`{"name":"replicasets","namespaced":true,"kind":"ReplicaSet"},` +
+				`{"name":"replicasets/status","namespaced":true,"kind":"ReplicaSet"},` +
+				`{"name":"statefulsets","namespaced":true,"kind":"StatefulSet"}`
+			if hasStatus {
+				resources += `,{"name":"deployments/status","namespaced":true,"kind":"Deployment"}`
+			}
+			w.Write([]byte(`{"kind":"APIResourceList","groupVersion":"apps/v1","resources":[` + resources + `]}`))
+		case r.Method == "PUT" && strings.HasSuffix(r.URL.Path, "/status"):
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","code":404}`))
+		}
+	}))
+	defer s.Close()
+
+	client := &k8s.Client{Endpoint: s.URL, Client: s.Client(), ContentType: k8s.ContentTypeJSON}
+	ctx := context.Background()
+	meta := func(name string) *metav1.ObjectMeta {
+		return &metav1.ObjectMeta{Name: k8s.String(name), Namespace: k8s.String("default")}
+	}
+	requests := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := discovery
+		discovery = 0
+		return n
+	}
+
+	rs := &appsv1.ReplicaSet{Metadata: meta("my-replicaset")}
+	if err := client.UpdateStatus(ctx, rs); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if n := requests(); n != 1 {
+		t.Errorf("expected 1 discovery request, got %d", n)
+	}
+
+	// The subresource is found once it's added, even though discovery was
+	// already cached.
+	mu.Lock()
+	hasStatus = true
+	mu.Unlock()
+	d := &appsv1.Deployment{
+		Metadata: meta("my-deployment"),
+		Status:   &appsv1.DeploymentStatus{Replicas: k8s.Int32(1)},
+	}
+	for i := 0; i < 3; i++ {
+		if err := client.UpdateStatus(ctx, d); err != nil {
+			t.Fatalf("update status: %v", err)
+		}
+	}
+	if n := requests(); n != 1 {
+		t.Errorf("expected discovery to be refreshed once then cached, got %d requests", n)
+	}
+
+	// Discovery was already refreshed, so resources without a status
+	// subresource fail without querying the API server again.
+	ss := &appsv1.StatefulSet{Metadata: meta("my-statefulset")}
+	for i := 0; i < 3; i++ {
+		if err := client.UpdateStatus(ctx, ss); err == nil {
+			t.Fatalf("expected error updating status of a resource without a status subresource")
+		}
+	}
+	if n := requests(); n != 0 {
+		t.Errorf("expected no discovery requests, got %d", n)
+	}
+}
@@ -0,0 +1,331 @@
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// ListEach lists resources like List, but decodes the items of the response
+// one at a time and passes them to fn, rather than reading the entire list into
+// memory. This keeps memory use proportional to the size of a single item for
+// large lists, such as all secrets or events in a cluster.
+//
+// The list's metadata, such as its resource version, is decoded into list. Its
+// items are left empty. If fn returns an error, the request is aborted and the
+// error is returned.
+//
+//	var secrets corev1.SecretList
+//	err := client.ListEach(ctx, k8s.AllNamespaces, &secrets, func(r k8s.Resource) error {
+//		secret := r.(*corev1.Secret)
+//		// ...
+//		return nil
+//	})
+func (c *Client) ListEach(ctx context.Context, namespace string, list ResourceList, fn func(item Resource) error, options ...Option) error {
+	url, err := resourceListURL(c.Endpoint, namespace, list, readOptions(options)...)
+	if err != nil {
+		return err
+	}
+	newItem, err := listItemType(list)
+	if err != nil {
+		return err
+	}
+	contentType := c.contentTypeFor(list, options)
+	err = c.stream(ctx, url, list, newItem, fn, contentType)
+	if contentType == contentTypePB && unsupportedContentType(err) {
+		return c.stream(ctx, url, list, newItem, fn, contentTypeJSON)
+	}
+	return err
+}
+
+// listItemType returns a function which allocates items of the list's type.
+func listItemType(list ResourceList) (func() Resource, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected pointer to a list struct, got %T", list)
+	}
+	field, ok := v.Elem().Type().FieldByName("Items")
+	if !ok || field.Type.Kind() != reflect.Slice || field.Type.Elem().Kind() != reflect.Ptr ||
+		!field.Type.Elem().Implements(reflect.TypeOf((*Resource)(nil)).Elem()) {
+		return nil, fmt.Errorf("%T doesn't have a slice of resources named Items", list)
+	}
+	t := field.Type.Elem().Elem()
+	return func() Resource {
+		return reflect.New(t).Interface().(Resource)
+	}, nil
+}
+
+func (c *Client) stream(ctx context.Context, url string, list ResourceList, newItem func() Resource, fn func(item Resource) error, preferred string) error {
+	r, err := c.newResourceRequest(ctx, "GET", url, nil, list, preferred)
+	if err != nil {
+		return err
+	}
+	re, err := c.send(r)
+	if err != nil {
+		return fmt.Errorf("performing request: %v", err)
+	}
+	defer re.Body.Close()
+
+	respCT := re.Header.Get("Content-Type")
+	if re.StatusCode/100 != 2 {
+		respBody, err := ioutil.ReadAll(re.Body)
+		if err != nil {
+			return fmt.Errorf("read body: %v", err)
+		}
+		return newAPIError(respCT, re.StatusCode, respBody)
+	}
+
+	each := func(item Resource) error {
+		setTypeMeta(item)
+		if err := fn(item); err != nil {
+			return &callbackError{err}
+		}
+		return nil
+	}
+	if _, ok := list.(proto.Message); ok && respCT == contentTypePB {
+		err = decodeListPB(bufio.NewReader(re.Body), list, newItem, each)
+	} else {
+		err = decodeListJSON(re.Body, list, newItem, each)
+	}
+	if cbErr, ok := err.(*callbackError); ok {
+		return cbErr.err
+	}
+	if err != nil {
+		return fmt.Errorf("decode response: %v", err)
+	}
+	setTypeMeta(list)
+	return nil
+}
+
+// callbackError wraps an error returned by a ListEach callback so it can be
+// told apart from decoding errors.
+type callbackError struct {
+	err error
+}
+
+func (e *callbackError) Error() string { return e.err.Error() }
+
+// decodeListJSON decodes a JSON list, passing each item to fn and all other
+// fields to list.
+func decodeListJSON(r io.Reader, list ResourceList, newItem func() Resource, fn func(item Resource) error) error {
+	d := json.NewDecoder(r)
+	if err := expectDelim(d, '{'); err != nil {
+		return err
+	}
+	fields := make(map[string]json.RawMessage)
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := t.(string)
+		if !ok {
+			return fmt.Errorf("expected object key, got %v", t)
+		}
+		if key != "items" {
+			var value json.RawMessage
+			if err := d.Decode(&value); err != nil {
+				return err
+			}
+			fields[key] = value
+			continue
+		}
+
+		t, err = d.Token()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			continue
+		}
+		if t != json.Delim('[') {
+			return fmt.Errorf("expected items to be an array, got %v", t)
+		}
+		for d.More() {
+			item := newItem()
+			if err := d.Decode(item); err != nil {
+				return err
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(d, ']'); err != nil {
+			return err
+		}
+	}
+	if err := expectDelim(d, '}'); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, list)
+}
+
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	t, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if t != delim {
+		return fmt.Errorf("expected %v, got %v", delim, t)
+	}
+	return nil
+}
+
+// decodeListPB decodes a list wrapped in a protobuf runtime.Unknown, passing
+// each item to fn and all other fields to list.
+//
+// The list is read field by field. Items are read into a buffer and decoded
+// individually, while the remaining fields, such as the list's metadata, are
+// collected and decoded once the list has been read.
+func decodeListPB(r *bufio.Reader, list ResourceList, newItem func() Resource, fn func(item Resource) error) error {
+	magic := make([]byte, len(magicBytes))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, magicBytes) {
+		return errors.New("payload is not a kubernetes protobuf object")
+	}
+	itemsField, err := protobufFieldNumber(list, "Items")
+	if err != nil {
+		return err
+	}
+
+	// Find the raw field of the runtime.Unknown, which holds the list.
+	for {
+		num, wireType, err := readTag(r)
+		if err == io.EOF {
+			return errors.New("protobuf object has no raw data")
+		}
+		if err != nil {
+			return err
+		}
+		if num == 2 && wireType == proto.WireBytes {
+			break
+		}
+		if _, err := readField(r, wireType, nil); err != nil {
+			return err
+		}
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	raw := bufio.NewReader(io.LimitReader(r, int64(n)))
+
+	var (
+		rest bytes.Buffer
+		buf  []byte
+	)
+	for {
+		num, wireType, err := readTag(raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if num != itemsField || wireType != proto.WireBytes {
+			writeUvarint(&rest, num<<3|uint64(wireType))
+			if _, err := readField(raw, wireType, &rest); err != nil {
+				return err
+			}
+			continue
+		}
+
+		size, err := binary.ReadUvarint(raw)
+		if err != nil {
+			return err
+		}
+		if uint64(cap(buf)) < size {
+			buf = make([]byte, size)
+		}
+		buf = buf[:size]
+		if _, err := io.ReadFull(raw, buf); err != nil {
+			return err
+		}
+		item := newItem()
+		msg, ok := item.(proto.Message)
+		if !ok {
+			return fmt.Errorf("%T is not a protobuf message", item)
+		}
+		if err := proto.Unmarshal(buf, msg); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return proto.Unmarshal(rest.Bytes(), list.(proto.Message))
+}
+
+// protobufFieldNumber returns the protobuf field number of a struct field, as
+// declared in its "protobuf" struct tag.
+func protobufFieldNumber(i interface{}, name string) (uint64, error) {
+	t := reflect.TypeOf(i).Elem()
+	field, ok := t.FieldByName(name)
+	if !ok {
+		return 0, fmt.Errorf("%s has no field %s", t, name)
+	}
+	parts := strings.Split(field.Tag.Get("protobuf"), ",")
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("field %s of %s has no protobuf tag", name, t)
+	}
+	return strconv.ParseUint(parts[1], 10, 64)
+}
+
+func readTag(r *bufio.Reader) (num uint64, wireType int, err error) {
+	tag, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	return tag >> 3, int(tag & 7), nil
+}
+
+// readField reads the value of a field with the given wire type, copying it to
+// w if it's not nil.
+func readField(r *bufio.Reader, wireType int, w *bytes.Buffer) (int64, error) {
+	if w == nil {
+		w = new(bytes.Buffer)
+	}
+	switch wireType {
+	case proto.WireVarint:
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return 0, err
+		}
+		return int64(writeUvarint(w, v)), nil
+	case proto.WireFixed64:
+		return io.CopyN(w, r, 8)
+	case proto.WireFixed32:
+		return io.CopyN(w, r, 4)
+	case proto.WireBytes:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return 0, err
+		}
+		writeUvarint(w, n)
+		return io.CopyN(w, r, int64(n))
+	default:
+		return 0, fmt.Errorf("unsupported protobuf wire type %d", wireType)
+	}
+}
+
+func writeUvarint(w *bytes.Buffer, v uint64) int {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	w.Write(buf[:n])
+	return n
+}
@@ -0,0 +1,88 @@
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+func TestListEach(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	var want []string
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("secret-%d", i)
+		secret := &corev1.Secret{
+			Metadata: &metav1.ObjectMeta{
+				Name:      k8s.String(name),
+				Namespace: k8s.String("default"),
+			},
+			StringData: map[string]string{"hello": "world"},
+		}
+		if err := client.Create(ctx, secret); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		want = append(want, name)
+	}
+
+	tests := []struct {
+		name    string
+		options []k8s.Option
+	}{
+		{"protobuf", nil},
+		{"json", []k8s.Option{k8s.ContentType(k8s.ContentTypeJSON)}},
+	}
+	for _, test := range tests {
+		var (
+			secrets corev1.SecretList
+			got     []string
+		)
+		err := client.ListEach(ctx, "default", &secrets, func(r k8s.Resource) error {
+			secret, ok := r.(*corev1.Secret)
+			if !ok {
+				return fmt.Errorf("unexpected item type %T", r)
+			}
+			got = append(got, secret.Metadata.GetName())
+			return nil
+		}, test.options...)
+		if err != nil {
+			t.Errorf("%s: list: %v", test.name, err)
+			continue
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: got items %q, want %q", test.name, got, want)
+		}
+		if secrets.Metadata.GetResourceVersion() == "" {
+			t.Errorf("%s: list metadata wasn't decoded", test.name)
+		}
+		if len(secrets.Items) != 0 {
+			t.Errorf("%s: expected no items in list, got %d", test.name, len(secrets.Items))
+		}
+
+		// Errors from the callback stop the list.
+		errStop := errors.New("stop")
+		n := 0
+		err = client.ListEach(ctx, "default", new(corev1.SecretList), func(r k8s.Resource) error {
+			n++
+			return errStop
+		}, test.options...)
+		if err != errStop {
+			t.Errorf("%s: expected callback error, got %v", test.name, err)
+		}
+		if n != 1 {
+			t.Errorf("%s: expected callback to be called once, got %d", test.name, n)
+		}
+	}
+}
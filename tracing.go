@@ -0,0 +1,168 @@
+package k8s
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Tracer starts spans for requests made by a Client. It's a minimal interface
+// which can be implemented by an adapter for a tracing library such as
+// OpenTelemetry. See the tracing package for an in-memory implementation.
+type Tracer interface {
+	// StartSpan starts a span as a child of any span in ctx, returning a
+	// context holding the new span.
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	// SpanContext returns the identity of the span, propagated to the API
+	// server using the traceparent header.
+	SpanContext() SpanContext
+
+	// SetAttribute records a property of the operation, such as "k8s.verb".
+	SetAttribute(key string, value interface{})
+
+	// SetError records that the operation failed.
+	SetError(err error)
+
+	// End completes the span.
+	End()
+}
+
+// SpanContext identifies a span within a trace, as defined by the W3C Trace
+// Context specification.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports if the trace and span IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats the span context as a traceparent header value, such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent header value. Servers can use it with
+// ContextWithSpanContext to continue a trace from an incoming request:
+//
+//	if sc, err := k8s.ParseTraceparent(r.Header.Get("traceparent")); err == nil {
+//		ctx = k8s.ContextWithSpanContext(ctx, sc)
+//	}
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	invalid := fmt.Errorf("invalid traceparent %q", s)
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 {
+		return sc, invalid
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	// Later versions may append fields, but version 00 has exactly four.
+	if !isLowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return sc, invalid
+	}
+	if !isLowerHex(traceID, 32) || !isLowerHex(spanID, 16) || !isLowerHex(flags, 2) {
+		return sc, invalid
+	}
+	hex.Decode(sc.TraceID[:], []byte(traceID))
+	hex.Decode(sc.SpanID[:], []byte(spanID))
+	if !sc.IsValid() {
+		return sc, invalid
+	}
+	var f [1]byte
+	hex.Decode(f[:], []byte(flags))
+	sc.Sampled = f[0]&1 == 1
+	return sc, nil
+}
+
+// isLowerHex reports if s is n lowercase hex digits, as required by
+// traceparent.
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a context holding the span context. Requests
+// made with the context are sent with a traceparent header, even if the client
+// has no Tracer.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context held by ctx, if any.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// startSpan starts a span for a request, returning the request with the span's
+// context and a traceparent header. The span is nil if the client has no
+// Tracer.
+func (c *Client) startSpan(req *http.Request, info *RequestInfo) (*http.Request, Span) {
+	var span Span
+	sc, ok := SpanContextFromContext(req.Context())
+	if c.Tracer != nil {
+		name := info.Verb + " " + info.Resource
+		if info.Resource == "" {
+			name = info.Method + " " + info.Path
+		}
+		var ctx context.Context
+		ctx, span = c.Tracer.StartSpan(req.Context(), name)
+		req = req.WithContext(ctx)
+		sc, ok = span.SpanContext(), span.SpanContext().IsValid()
+
+		span.SetAttribute("http.method", info.Method)
+		for _, attr := range []struct{ key, value string }{
+			{"k8s.verb", info.Verb},
+			{"k8s.group", info.Group},
+			{"k8s.version", info.Version},
+			{"k8s.resource", info.Resource},
+			{"k8s.subresource", info.Subresource},
+			{"k8s.namespace", info.Namespace},
+			{"k8s.name", info.Name},
+		} {
+			if attr.value != "" {
+				span.SetAttribute(attr.key, attr.value)
+			}
+		}
+	}
+	if ok && req.Header.Get("traceparent") == "" {
+		req.Header.Set("traceparent", sc.Traceparent())
+	}
+	return req, span
+}
+
+// endSpan records the outcome of a request and ends its span.
+func endSpan(span Span, info *RequestInfo) {
+	if info.Err != nil {
+		span.SetError(info.Err)
+	} else {
+		span.SetAttribute("http.status_code", info.StatusCode)
+		if info.StatusCode >= 400 {
+			span.SetError(fmt.Errorf("%d %s", info.StatusCode, http.StatusText(info.StatusCode)))
+		}
+	}
+	span.End()
+}
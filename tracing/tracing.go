@@ -0,0 +1,153 @@
+/*
+Package tracing implements a k8s.Tracer which exports completed spans, with an
+in-memory exporter for tests.
+
+	exporter := new(tracing.InMemoryExporter)
+	client.Tracer = tracing.NewTracer(exporter)
+
+	// Make requests...
+
+	for _, span := range exporter.Spans() {
+		fmt.Println(span.Name, span.Attributes["http.status_code"], span.End.Sub(span.Start))
+	}
+
+Applications using a tracing library such as OpenTelemetry should instead
+implement k8s.Tracer with an adapter for that library.
+*/
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+
+	"github.com/ericchiang/k8s"
+)
+
+// SpanData is a completed span.
+type SpanData struct {
+	Name        string
+	SpanContext k8s.SpanContext
+
+	// Parent is the span context of the parent span, invalid for root spans.
+	Parent k8s.SpanContext
+
+	Start time.Time
+	End   time.Time
+
+	Attributes map[string]interface{}
+	Err        error
+}
+
+// Exporter receives spans as they end.
+type Exporter interface {
+	ExportSpan(span *SpanData)
+}
+
+// InMemoryExporter stores exported spans. The zero value is ready to use.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*SpanData
+}
+
+// ExportSpan implements Exporter.
+func (e *InMemoryExporter) ExportSpan(span *SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans, in the order they ended.
+func (e *InMemoryExporter) Spans() []*SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*SpanData(nil), e.spans...)
+}
+
+// Reset discards the exported spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+// Tracer is a k8s.Tracer which sends completed spans to an exporter.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer returns a tracer exporting spans to e.
+func NewTracer(e Exporter) *Tracer {
+	return &Tracer{exporter: e}
+}
+
+// StartSpan implements k8s.Tracer. The span continues the trace of any span
+// context held by ctx, such as one set by k8s.ContextWithSpanContext, and is
+// sampled unless the parent isn't.
+func (t *Tracer) StartSpan(ctx context.Context, name string) (context.Context, k8s.Span) {
+	s := &span{
+		tracer: t,
+		data: SpanData{
+			Name:       name,
+			Start:      time.Now(),
+			Attributes: make(map[string]interface{}),
+		},
+	}
+	sc := k8s.SpanContext{Sampled: true}
+	if parent, ok := k8s.SpanContextFromContext(ctx); ok {
+		s.data.Parent = parent
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		rand.Read(sc.TraceID[:])
+	}
+	rand.Read(sc.SpanID[:])
+	s.data.SpanContext = sc
+	return k8s.ContextWithSpanContext(ctx, sc), s
+}
+
+type span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *span) SpanContext() k8s.SpanContext {
+	return s.data.SpanContext
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Attributes[key] = value
+	}
+}
+
+func (s *span) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Err = err
+	}
+}
+
+// End exports the span. Later calls do nothing.
+func (s *span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled {
+		s.tracer.exporter.ExportSpan(&data)
+	}
+}
@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+func TestClientSpans(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+
+	exporter := new(InMemoryExporter)
+	client := srv.Client()
+	client.Tracer = NewTracer(exporter)
+
+	parent, err := k8s.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := k8s.ContextWithSpanContext(context.Background(), parent)
+
+	cm := &corev1.ConfigMap{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-configmap"),
+			Namespace: k8s.String("default"),
+		},
+	}
+	if err := client.Create(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Get(ctx, "default", "missing", cm); err == nil {
+		t.Fatal("expected not found error")
+	}
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	create, get := spans[0], spans[1]
+
+	if create.Name != "create configmaps" {
+		t.Errorf("unexpected span name %q", create.Name)
+	}
+	if create.Parent != parent || create.SpanContext.TraceID != parent.TraceID {
+		t.Errorf("span didn't continue the trace of the context")
+	}
+	want := map[string]interface{}{
+		"http.method":      "POST",
+		"http.status_code": http.StatusCreated,
+		"k8s.verb":         "create",
+		"k8s.version":      "v1",
+		"k8s.resource":     "configmaps",
+		"k8s.namespace":    "default",
+	}
+	for k, v := range want {
+		if create.Attributes[k] != v {
+			t.Errorf("attribute %s: got %v, want %v", k, create.Attributes[k], v)
+		}
+	}
+	if create.Err != nil {
+		t.Errorf("unexpected span error %v", create.Err)
+	}
+
+	if get.Attributes["k8s.name"] != "missing" || get.Attributes["http.status_code"] != http.StatusNotFound {
+		t.Errorf("unexpected attributes %v", get.Attributes)
+	}
+	if get.Err == nil {
+		t.Errorf("expected span error for not found response")
+	}
+}
+
+func TestTraceparentHeader(t *testing.T) {
+	var got []string
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	transport := client.Client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client.Client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = append(got, r.Header.Get("traceparent"))
+		return transport.RoundTrip(r)
+	})}
+
+	sc, err := k8s.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := k8s.ContextWithSpanContext(context.Background(), sc)
+
+	// Without a tracer, the span context is propagated as is.
+	var cm corev1.ConfigMap
+	client.Get(ctx, "default", "missing", &cm)
+
+	// With a tracer, the header identifies the request's span.
+	exporter := new(InMemoryExporter)
+	client.Tracer = NewTracer(exporter)
+	client.Get(ctx, "default", "missing", &cm)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(got))
+	}
+	if got[0] != sc.Traceparent() {
+		t.Errorf("expected traceparent %q, got %q", sc.Traceparent(), got[0])
+	}
+	spans := exporter.Spans()
+	if len(spans) != 1 || got[1] != spans[0].SpanContext.Traceparent() {
+		t.Errorf("traceparent %q didn't identify the request's span", got[1])
+	}
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,58 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		header  string
+		wantErr bool
+		sampled bool
+	}{
+		{header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sampled: true},
+		{header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", sampled: true},
+		{header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: true},
+		{header: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		{header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{header: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		{header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", wantErr: true},
+		{header: "", wantErr: true},
+	}
+	for _, test := range tests {
+		sc, err := ParseTraceparent(test.header)
+		if err != nil {
+			if !test.wantErr {
+				t.Errorf("parse %q: %v", test.header, err)
+			}
+			continue
+		}
+		if test.wantErr {
+			t.Errorf("parse %q: expected error", test.header)
+			continue
+		}
+		if sc.Sampled != test.sampled {
+			t.Errorf("parse %q: expected sampled=%t", test.header, test.sampled)
+		}
+		if test.header[:2] == "00" && sc.Traceparent() != test.header {
+			t.Errorf("round trip of %q: got %q", test.header, sc.Traceparent())
+		}
+	}
+}
+
+func TestSpanContextFromContext(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := SpanContextFromContext(ctx); ok {
+		t.Errorf("expected no span context")
+	}
+	if _, ok := SpanContextFromContext(ContextWithSpanContext(ctx, SpanContext{})); ok {
+		t.Errorf("expected invalid span context to be ignored")
+	}
+	sc := SpanContext{TraceID: [16]byte{1}, SpanID: [8]byte{2}}
+	if got, ok := SpanContextFromContext(ContextWithSpanContext(ctx, sc)); !ok || got != sc {
+		t.Errorf("expected span context %v, got %v", sc, got)
+	}
+}
@@ -0,0 +1,129 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// TypeMeta holds the API version and kind of an object.
+//
+// Generated types don't hold their API version or kind, but custom resource
+// types can embed TypeMeta. The client then populates these fields when
+// sending and receiving objects, as required by the API server for custom
+// resources.
+//
+//		type MyResource struct {
+//			k8s.TypeMeta
+//			Metadata *metav1.ObjectMeta `json:"metadata"`
+//			Spec     MyResourceSpec      `json:"spec"`
+//		}
+//
+type TypeMeta struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+}
+
+func (t *TypeMeta) GetAPIVersion() string           { return t.APIVersion }
+func (t *TypeMeta) GetKind() string                 { return t.Kind }
+func (t *TypeMeta) SetAPIVersion(apiVersion string) { t.APIVersion = apiVersion }
+func (t *TypeMeta) SetKind(kind string)             { t.Kind = kind }
+
+// typeMetaAccessor is implemented by types that hold their API version and kind,
+// such as types embedding TypeMeta and Unstructured.
+type typeMetaAccessor interface {
+	GetAPIVersion() string
+	GetKind() string
+	SetAPIVersion(apiVersion string)
+	SetKind(kind string)
+}
+
+func (t resourceType) typeMeta(kind string) TypeMeta {
+	apiVersion := t.apiVersion
+	if t.apiGroup != "" {
+		apiVersion = t.apiGroup + "/" + t.apiVersion
+	}
+	return TypeMeta{APIVersion: apiVersion, Kind: kind}
+}
+
+// registeredTypeMeta returns the type meta of a registered resource or resource
+// list type.
+func registeredTypeMeta(i interface{}) (TypeMeta, bool) {
+	rt := reflect.TypeOf(i)
+	if t, ok := resources[rt]; ok {
+		return t.typeMeta(t.kind), true
+	}
+	if t, ok := resourceLists[rt]; ok {
+		return t.typeMeta(t.kind), true
+	}
+	return TypeMeta{}, false
+}
+
+// TypeMetaFor returns the API version and kind of an object. For registered
+// types, these are determined by how the type was registered. Otherwise the
+// object must hold its own type information, such as an Unstructured object.
+//
+//		tm, ok := k8s.TypeMetaFor(&appsv1.Deployment{})
+//		fmt.Println(tm.APIVersion, tm.Kind) // "apps/v1" "Deployment"
+//
+func TypeMetaFor(r Resource) (TypeMeta, bool) {
+	if tm, ok := registeredTypeMeta(r); ok {
+		return tm, true
+	}
+	if a, ok := r.(typeMetaAccessor); ok && a.GetAPIVersion() != "" && a.GetKind() != "" {
+		return TypeMeta{APIVersion: a.GetAPIVersion(), Kind: a.GetKind()}, true
+	}
+	return TypeMeta{}, false
+}
+
+// SetTypeMeta populates the API version and kind fields of a registered
+// resource or resource list, if its type holds them. Types that don't hold
+// type information, such as generated types, are left unchanged.
+func SetTypeMeta(i interface{}) error {
+	tm, ok := registeredTypeMeta(i)
+	if !ok {
+		return fmt.Errorf("unregistered type %T", i)
+	}
+	if a, ok := i.(typeMetaAccessor); ok {
+		a.SetAPIVersion(tm.APIVersion)
+		a.SetKind(tm.Kind)
+	}
+	return nil
+}
+
+// setTypeMeta populates empty type information of objects sent to or received
+// from the API server.
+func setTypeMeta(i interface{}) {
+	a, ok := i.(typeMetaAccessor)
+	if !ok || (a.GetAPIVersion() != "" && a.GetKind() != "") {
+		return
+	}
+	if tm, ok := registeredTypeMeta(i); ok {
+		a.SetAPIVersion(tm.APIVersion)
+		a.SetKind(tm.Kind)
+	}
+}
+
+// EncodeJSON encodes an object as JSON, including its "apiVersion" and "kind"
+// fields even if the Go type doesn't hold them. This is useful for writing
+// objects to files or logs.
+func EncodeJSON(r Resource) ([]byte, error) {
+	tm, ok := TypeMetaFor(r)
+	if !ok {
+		return nil, fmt.Errorf("unable to determine apiVersion and kind of %T", r)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		fields = map[string]json.RawMessage{}
+	}
+	fields["apiVersion"], _ = json.Marshal(tm.APIVersion)
+	fields["kind"], _ = json.Marshal(tm.Kind)
+	return json.Marshal(fields)
+}
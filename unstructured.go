@@ -0,0 +1,212 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+// Unstructured is an API object of an arbitrary kind, represented as decoded JSON.
+// It's used to interact with resources that don't have a registered Go type,
+// such as custom resources discovered at runtime.
+//
+// Unstructured objects can only be encoded as JSON. Numbers are decoded as
+// json.Number values to avoid losing precision.
+type Unstructured struct {
+	Object map[string]interface{}
+}
+
+// UnstructuredList is a list of Unstructured objects.
+type UnstructuredList struct {
+	// Object holds all fields of the list other than "items", such as "apiVersion"
+	// and "metadata".
+	Object map[string]interface{}
+	Items  []*Unstructured
+}
+
+func decodeJSONObject(data []byte) (map[string]interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var obj map[string]interface{}
+	if err := d.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("expected json object, got null")
+	}
+	return obj, nil
+}
+
+func (u *Unstructured) MarshalJSON() ([]byte, error) {
+	if u.Object == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(u.Object)
+}
+
+func (u *Unstructured) UnmarshalJSON(data []byte) error {
+	obj, err := decodeJSONObject(data)
+	if err != nil {
+		return err
+	}
+	u.Object = obj
+	return nil
+}
+
+func (l *UnstructuredList) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]interface{}, len(l.Object)+1)
+	for k, v := range l.Object {
+		obj[k] = v
+	}
+	items := make([]interface{}, len(l.Items))
+	for i, item := range l.Items {
+		items[i] = item
+	}
+	obj["items"] = items
+	return json.Marshal(obj)
+}
+
+func (l *UnstructuredList) UnmarshalJSON(data []byte) error {
+	var list struct {
+		Items []*Unstructured `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	obj, err := decodeJSONObject(data)
+	if err != nil {
+		return err
+	}
+	delete(obj, "items")
+	l.Object = obj
+	l.Items = list.Items
+	return nil
+}
+
+// GetMetadata returns a copy of the object's metadata. Modifying the returned
+// value doesn't update the object, use the setter methods such as SetLabels
+// instead.
+//
+// GetMetadata allows Unstructured to be used as a Resource, for example with
+// Watcher.Next.
+func (u *Unstructured) GetMetadata() *metav1.ObjectMeta {
+	meta := new(metav1.ObjectMeta)
+	decodeField(u.Object, "metadata", meta)
+	return meta
+}
+
+// GetMetadata returns a copy of the list's metadata.
+func (l *UnstructuredList) GetMetadata() *metav1.ListMeta {
+	meta := new(metav1.ListMeta)
+	decodeField(l.Object, "metadata", meta)
+	return meta
+}
+
+// decodeField converts a field of a JSON object into a typed value, ignoring
+// errors.
+func decodeField(obj map[string]interface{}, key string, v interface{}) {
+	field, ok := obj[key]
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(field)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, v)
+}
+
+func (u *Unstructured) getString(key string) string {
+	s, _ := u.Object[key].(string)
+	return s
+}
+
+func (u *Unstructured) metadata(create bool) map[string]interface{} {
+	meta, ok := u.Object["metadata"].(map[string]interface{})
+	if !ok && create {
+		if u.Object == nil {
+			u.Object = map[string]interface{}{}
+		}
+		meta = map[string]interface{}{}
+		u.Object["metadata"] = meta
+	}
+	return meta
+}
+
+func (u *Unstructured) getMetaString(key string) string {
+	s, _ := u.metadata(false)[key].(string)
+	return s
+}
+
+func (u *Unstructured) setMetaString(key, val string) {
+	if val == "" {
+		delete(u.metadata(false), key)
+		return
+	}
+	u.metadata(true)[key] = val
+}
+
+func (u *Unstructured) getMetaMap(key string) map[string]string {
+	m, ok := u.metadata(false)[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
+
+func (u *Unstructured) setMetaMap(key string, val map[string]string) {
+	if val == nil {
+		delete(u.metadata(false), key)
+		return
+	}
+	m := make(map[string]interface{}, len(val))
+	for k, v := range val {
+		m[k] = v
+	}
+	u.metadata(true)[key] = m
+}
+
+// GetAPIVersion returns the "apiVersion" field of the object, such as "apps/v1".
+func (u *Unstructured) GetAPIVersion() string { return u.getString("apiVersion") }
+
+// GetKind returns the "kind" field of the object, such as "Deployment".
+func (u *Unstructured) GetKind() string { return u.getString("kind") }
+
+// SetAPIVersion sets the "apiVersion" field of the object.
+func (u *Unstructured) SetAPIVersion(apiVersion string) {
+	if u.Object == nil {
+		u.Object = map[string]interface{}{}
+	}
+	u.Object["apiVersion"] = apiVersion
+}
+
+// SetKind sets the "kind" field of the object.
+func (u *Unstructured) SetKind(kind string) {
+	if u.Object == nil {
+		u.Object = map[string]interface{}{}
+	}
+	u.Object["kind"] = kind
+}
+
+func (u *Unstructured) GetName() string            { return u.getMetaString("name") }
+func (u *Unstructured) GetNamespace() string       { return u.getMetaString("namespace") }
+func (u *Unstructured) GetUID() string             { return u.getMetaString("uid") }
+func (u *Unstructured) GetResourceVersion() string { return u.getMetaString("resourceVersion") }
+
+func (u *Unstructured) SetName(name string)           { u.setMetaString("name", name) }
+func (u *Unstructured) SetNamespace(namespace string) { u.setMetaString("namespace", namespace) }
+func (u *Unstructured) SetResourceVersion(rv string)  { u.setMetaString("resourceVersion", rv) }
+
+func (u *Unstructured) GetLabels() map[string]string      { return u.getMetaMap("labels") }
+func (u *Unstructured) GetAnnotations() map[string]string { return u.getMetaMap("annotations") }
+
+func (u *Unstructured) SetLabels(labels map[string]string)           { u.setMetaMap("labels", labels) }
+func (u *Unstructured) SetAnnotations(annotations map[string]string) { u.setMetaMap("annotations", annotations) }
@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUnstructuredJSON(t *testing.T) {
+	data := []byte(`{
+		"apiVersion": "example.com/v1",
+		"kind": "Widget",
+		"metadata": {
+			"name": "my-widget",
+			"namespace": "my-namespace",
+			"resourceVersion": "12",
+			"labels": {"app": "widgets"}
+		},
+		"spec": {"size": 9007199254740993}
+	}`)
+
+	u := new(Unstructured)
+	if err := json.Unmarshal(data, u); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if got, want := u.GetAPIVersion(), "example.com/v1"; got != want {
+		t.Errorf("wanted apiVersion=%q, got=%q", want, got)
+	}
+	if got, want := u.GetKind(), "Widget"; got != want {
+		t.Errorf("wanted kind=%q, got=%q", want, got)
+	}
+	if got, want := u.GetName(), "my-widget"; got != want {
+		t.Errorf("wanted name=%q, got=%q", want, got)
+	}
+	if got, want := u.GetMetadata().GetNamespace(), "my-namespace"; got != want {
+		t.Errorf("wanted namespace=%q, got=%q", want, got)
+	}
+	if got, want := u.GetLabels(), map[string]string{"app": "widgets"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted labels=%v, got=%v", want, got)
+	}
+
+	u.SetNamespace("other-namespace")
+	u.SetResourceVersion("")
+	u.SetAnnotations(map[string]string{"foo": "bar"})
+
+	out, err := json.Marshal(u)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"annotations":{"foo":"bar"},"labels":{"app":"widgets"},"name":"my-widget","namespace":"other-namespace"},"spec":{"size":9007199254740993}}`
+	if string(out) != want {
+		t.Errorf("wanted=%s\ngot=%s", want, out)
+	}
+}
+
+func TestUnstructuredListJSON(t *testing.T) {
+	data := []byte(`{
+		"apiVersion": "example.com/v1",
+		"kind": "WidgetList",
+		"metadata": {"resourceVersion": "42"},
+		"items": [
+			{"metadata": {"name": "a"}},
+			{"metadata": {"name": "b"}}
+		]
+	}`)
+
+	l := new(UnstructuredList)
+	if err := json.Unmarshal(data, l); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got, want := l.GetMetadata().GetResourceVersion(), "42"; got != want {
+		t.Errorf("wanted resourceVersion=%q, got=%q", want, got)
+	}
+	if _, ok := l.Object["items"]; ok {
+		t.Errorf("expected items to be removed from list object")
+	}
+	var names []string
+	for _, item := range l.Items {
+		names = append(names, item.GetName())
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("wanted items=%v, got=%v", want, names)
+	}
+}
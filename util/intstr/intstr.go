@@ -0,0 +1,131 @@
+package intstr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Constructors, accessors and JSON marshaling logic for the IntOrString type,
+// which the API encodes as a bare integer or string, such as 8080 or "http".
+
+// Type is the type of value held by an IntOrString.
+type Type int64
+
+const (
+	Int    Type = iota // The IntOrString holds an int.
+	String             // The IntOrString holds a string.
+)
+
+// FromInt returns an IntOrString holding an int.
+func FromInt(val int) *IntOrString {
+	t, v := int64(Int), int32(val)
+	return &IntOrString{Type: &t, IntVal: &v}
+}
+
+// FromString returns an IntOrString holding a string.
+func FromString(val string) *IntOrString {
+	t := int64(String)
+	return &IntOrString{Type: &t, StrVal: &val}
+}
+
+// Parse returns an IntOrString holding an int if val is an integer, and a
+// string otherwise.
+func Parse(val string) *IntOrString {
+	i, err := strconv.ParseInt(val, 10, 32)
+	if err != nil {
+		return FromString(val)
+	}
+	return FromInt(int(i))
+}
+
+// ValueType returns the type of value held. An IntOrString with no type set
+// holds a string if only StrVal is set, and an int otherwise.
+func (m *IntOrString) ValueType() Type {
+	if m == nil {
+		return Int
+	}
+	if m.Type == nil {
+		if m.StrVal != nil && m.IntVal == nil {
+			return String
+		}
+		return Int
+	}
+	return Type(*m.Type)
+}
+
+// IntValue returns the int held, or the string held converted to an int. It
+// returns 0 for strings which aren't integers.
+func (m *IntOrString) IntValue() int {
+	if m.ValueType() == String {
+		i, _ := strconv.Atoi(m.GetStrVal())
+		return i
+	}
+	return int(m.GetIntVal())
+}
+
+// StringValue returns the string held, or the int held formatted as a string.
+func (m *IntOrString) StringValue() string {
+	if m.ValueType() == String {
+		return m.GetStrVal()
+	}
+	return strconv.Itoa(int(m.GetIntVal()))
+}
+
+func (m IntOrString) MarshalJSON() ([]byte, error) {
+	if m.ValueType() == String {
+		return json.Marshal(m.GetStrVal())
+	}
+	return json.Marshal(m.GetIntVal())
+}
+
+func (m *IntOrString) UnmarshalJSON(p []byte) error {
+	if string(p) == "null" {
+		*m = IntOrString{}
+		return nil
+	}
+	if len(p) > 0 && p[0] == '"' {
+		var s string
+		if err := json.Unmarshal(p, &s); err != nil {
+			return err
+		}
+		*m = *FromString(s)
+		return nil
+	}
+	var i int32
+	if err := json.Unmarshal(p, &i); err != nil {
+		return fmt.Errorf("intstr: expected an integer or string, got %s", p)
+	}
+	*m = *FromInt(int(i))
+	return nil
+}
+
+// GetScaledValueFromIntOrPercent returns the int held by intOrPercent, or the
+// percentage it holds applied to total, such as "25%" of 10. Fractional
+// results are rounded up if roundUp is true, and down otherwise.
+//
+// It's used to resolve fields such as a deployment's maxSurge and
+// maxUnavailable.
+func GetScaledValueFromIntOrPercent(intOrPercent *IntOrString, total int, roundUp bool) (int, error) {
+	if intOrPercent == nil {
+		return 0, fmt.Errorf("nil value for IntOrString")
+	}
+	if intOrPercent.ValueType() == Int {
+		return int(intOrPercent.GetIntVal()), nil
+	}
+	s := intOrPercent.GetStrVal()
+	if !strings.HasSuffix(s, "%") {
+		return 0, fmt.Errorf("invalid type: string is not a percentage")
+	}
+	v, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for IntOrString: invalid value %q: %v", s, err)
+	}
+	scaled := float64(v) * float64(total) / 100
+	if roundUp {
+		return int(math.Ceil(scaled)), nil
+	}
+	return int(math.Floor(scaled)), nil
+}
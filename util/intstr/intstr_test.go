@@ -0,0 +1,93 @@
+package intstr
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	type port struct {
+		Port       int32        `json:"port"`
+		TargetPort *IntOrString `json:"targetPort,omitempty"`
+	}
+	tests := []struct {
+		json string
+		port port
+	}{
+		{`{"port":80,"targetPort":8080}`, port{80, FromInt(8080)}},
+		{`{"port":80,"targetPort":"http"}`, port{80, FromString("http")}},
+		{`{"port":80}`, port{80, nil}},
+	}
+	for _, test := range tests {
+		data, err := json.Marshal(test.port)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.json {
+			t.Errorf("marshal: got %s, want %s", data, test.json)
+		}
+		var got port
+		if err := json.Unmarshal([]byte(test.json), &got); err != nil {
+			t.Fatalf("unmarshal %s: %v", test.json, err)
+		}
+		if got.TargetPort.StringValue() != test.port.TargetPort.StringValue() || got.TargetPort.ValueType() != test.port.TargetPort.ValueType() {
+			t.Errorf("unmarshal %s: got %v, want %v", test.json, got.TargetPort, test.port.TargetPort)
+		}
+	}
+
+	var p port
+	if err := json.Unmarshal([]byte(`{"targetPort":1.5}`), &p); err == nil {
+		t.Errorf("expected error for non-integer number")
+	}
+
+	// Values constructed without a type are encoded by the field set.
+	s := "http"
+	data, err := json.Marshal(IntOrString{StrVal: &s})
+	if err != nil || string(data) != `"http"` {
+		t.Errorf("marshal without type: got %s, %v", data, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if v := Parse("8080"); v.ValueType() != Int || v.IntValue() != 8080 {
+		t.Errorf("parse 8080: got %v", v)
+	}
+	if v := Parse("http"); v.ValueType() != String || v.StringValue() != "http" {
+		t.Errorf("parse http: got %v", v)
+	}
+}
+
+func TestGetScaledValueFromIntOrPercent(t *testing.T) {
+	tests := []struct {
+		in      *IntOrString
+		total   int
+		roundUp bool
+		want    int
+		wantErr bool
+	}{
+		{in: FromInt(3), total: 10, want: 3},
+		{in: FromString("25%"), total: 10, roundUp: true, want: 3},
+		{in: FromString("25%"), total: 10, want: 2},
+		{in: FromString("100%"), total: 7, want: 7},
+		{in: FromString("0%"), total: 7, roundUp: true, want: 0},
+		{in: FromString("25"), total: 10, wantErr: true},
+		{in: FromString("a%"), total: 10, wantErr: true},
+		{in: nil, total: 10, wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := GetScaledValueFromIntOrPercent(test.in, test.total, test.roundUp)
+		if err != nil {
+			if !test.wantErr {
+				t.Errorf("%s of %d: %v", test.in.StringValue(), test.total, err)
+			}
+			continue
+		}
+		if test.wantErr {
+			t.Errorf("%s of %d: expected error", test.in.StringValue(), test.total)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s of %d (round up %t): got %d, want %d", test.in.StringValue(), test.total, test.roundUp, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Package wait waits for resources to reach a desired state, such as a Deployment
+becoming available or a Job completing.
+
+	var deployment appsv1.Deployment
+	if err := client.Get(ctx, "my-namespace", "my-deployment", &deployment); err != nil {
+		// handle error
+	}
+	if err := wait.For(ctx, client, &deployment, wait.DeploymentAvailable, 5*time.Minute); err != nil {
+		// handle error
+	}
+
+The resource is fetched once, then followed with a watch scoped to its name,
+which is reestablished after a short delay if it ends before the predicate is
+satisfied. The delay increases while watches end without delivering events. Watch errors such as missing permissions end the wait.
+Predicates are provided for the standard status conditions of core, apps,
+batch and apiextensions types, and work with both generated types and
+k8s.Unstructured.
+*/
+package wait
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	"github.com/ericchiang/k8s/internal/retry"
+)
+
+// Client is the subset of k8s.Interface used to wait for resources. It's
+// implemented by *k8s.Client.
+type Client interface {
+	k8s.Reader
+	k8s.WatcherFactory
+}
+
+// Predicate reports if a resource has reached the desired state. Returning an
+// error stops the wait, for example when a Job has failed and will never
+// complete.
+type Predicate func(r k8s.Resource) (bool, error)
+
+// For waits until the resource with the name and namespace of r satisfies the
+// predicate, or the timeout expires. A timeout of zero waits until ctx is
+// canceled. Resources which don't exist yet, or are deleted while waiting, are
+// waited for until they're created.
+//
+// r is updated with the last version of the resource the predicate was called
+// with, so callers can inspect the resource after a predicate error.
+func For(ctx context.Context, c Client, r k8s.Resource, predicate Predicate, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	meta := r.GetMetadata()
+	namespace, name := meta.GetNamespace(), meta.GetName()
+	desc := name
+	if namespace != "" {
+		desc = namespace + "/" + name
+	}
+
+	// check calls the predicate, updating r with the resource it was called
+	// with.
+	check := func(current k8s.Resource) (bool, error) {
+		ok, err := predicate(current)
+		reflect.ValueOf(r).Elem().Set(reflect.ValueOf(current).Elem())
+		return ok, err
+	}
+	timedOut := func(err error) error {
+		if ctx.Err() != nil {
+			return fmt.Errorf("waiting for %s: %v", desc, ctx.Err())
+		}
+		return err
+	}
+
+	var backoff retry.Backoff
+	for {
+		var resourceVersion string
+		current := newResource(r)
+		err := c.Get(ctx, namespace, name, current)
+		switch apiErr, _ := err.(*k8s.APIError); {
+		case apiErr != nil && apiErr.Code == http.StatusNotFound:
+			// Watch for the resource to be created.
+		case err != nil:
+			return timedOut(fmt.Errorf("get %s: %v", desc, err))
+		default:
+			if ok, err := check(current); ok || err != nil {
+				return err
+			}
+			resourceVersion = current.GetMetadata().GetResourceVersion()
+		}
+
+		options := []k8s.Option{k8s.QueryParam("fieldSelector", "metadata.name="+name)}
+		if resourceVersion != "" {
+			options = append(options, k8s.ResourceVersion(resourceVersion))
+		}
+		w, err := c.Watch(ctx, namespace, newResource(r), options...)
+		if err != nil {
+			return timedOut(fmt.Errorf("watch %s: %v", desc, err))
+		}
+		ok, err := follow(w, r, desc, check, &backoff)
+		w.Close()
+		if ok || err != nil {
+			return err
+		}
+
+		// The watch ended, fetch the resource again and start a new one. Wait
+		// first, so a watch which keeps failing doesn't turn into a tight
+		// loop of requests.
+		if err := backoff.Wait(ctx); err != nil {
+			return timedOut(err)
+		}
+	}
+}
+
+// follow checks each version of the resource sent by a watch, until the
+// predicate is satisfied or the watch ends. Watch errors which a new watch
+// won't fix, such as missing permissions, are returned. The backoff is reset
+// by each event, so only watches which fail without making progress back off.
+func follow(w *k8s.Watcher, r k8s.Resource, desc string, check func(k8s.Resource) (bool, error), backoff *retry.Backoff) (bool, error) {
+	for {
+		event := newResource(r)
+		eventType, err := w.Next(event)
+		if err != nil {
+			if apiErr, ok := err.(*k8s.APIError); ok && !retry.Retryable(apiErr.Code) {
+				return false, fmt.Errorf("watch %s: %v", desc, err)
+			}
+			return false, nil
+		}
+		backoff.Reset()
+		if eventType != k8s.EventAdded && eventType != k8s.EventModified {
+			continue
+		}
+		if ok, err := check(event); ok || err != nil {
+			return ok, err
+		}
+	}
+}
+
+func newResource(r k8s.Resource) k8s.Resource {
+	return reflect.New(reflect.TypeOf(r).Elem()).Interface().(k8s.Resource)
+}
+
+// Condition is a standard status condition, found in the status.conditions
+// field of many resources.
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// status holds the common fields of a resource's status.
+type status struct {
+	ObservedGeneration *int64      `json:"observedGeneration"`
+	Phase              string      `json:"phase"`
+	Conditions         []Condition `json:"conditions"`
+}
+
+func statusOf(r k8s.Resource) (*status, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("encode %T: %v", r, err)
+	}
+	var obj struct {
+		Status status `json:"status"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("decode status of %T: %v", r, err)
+	}
+	return &obj.Status, nil
+}
+
+func (s *status) condition(conditionType string) (Condition, bool) {
+	for _, c := range s.Conditions {
+		if c.Type == conditionType {
+			return c, true
+		}
+	}
+	return Condition{}, false
+}
+
+// Conditions returns the status conditions of a resource.
+func Conditions(r k8s.Resource) ([]Condition, error) {
+	s, err := statusOf(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.Conditions, nil
+}
+
+// conditionTrue reports if a resource has a condition with status "True",
+// and has observed its latest generation.
+func conditionTrue(r k8s.Resource, s *status, conditionType string) bool {
+	if s.ObservedGeneration != nil && *s.ObservedGeneration < r.GetMetadata().GetGeneration() {
+		return false
+	}
+	c, ok := s.condition(conditionType)
+	return ok && c.Status == "True"
+}
+
+// ConditionTrue returns a predicate satisfied once a resource has a condition
+// of the given type with status "True".
+//
+// Resources which report status.observedGeneration must also have observed
+// their latest generation, so conditions from before an update aren't mistaken
+// for the result of it.
+func ConditionTrue(conditionType string) Predicate {
+	return func(r k8s.Resource) (bool, error) {
+		s, err := statusOf(r)
+		if err != nil {
+			return false, err
+		}
+		return conditionTrue(r, s, conditionType), nil
+	}
+}
+
+// PodReady is satisfied once a Pod is ready to serve requests. It fails if the
+// pod has terminated.
+func PodReady(r k8s.Resource) (bool, error) {
+	s, err := statusOf(r)
+	if err != nil {
+		return false, err
+	}
+	if s.Phase == "Succeeded" || s.Phase == "Failed" {
+		return false, fmt.Errorf("pod %s has terminated with phase %s", r.GetMetadata().GetName(), s.Phase)
+	}
+	return conditionTrue(r, s, "Ready"), nil
+}
+
+// NodeReady is satisfied once a Node is ready to run pods.
+func NodeReady(r k8s.Resource) (bool, error) {
+	return ConditionTrue("Ready")(r)
+}
+
+// DeploymentAvailable is satisfied once a Deployment has the minimum number of
+// available replicas. It fails if the Deployment's rollout has exceeded its
+// progress deadline.
+func DeploymentAvailable(r k8s.Resource) (bool, error) {
+	s, err := statusOf(r)
+	if err != nil {
+		return false, err
+	}
+	if c, ok := s.condition("Progressing"); ok && c.Status == "False" && c.Reason == "ProgressDeadlineExceeded" {
+		return false, fmt.Errorf("deployment %s exceeded its progress deadline: %s", r.GetMetadata().GetName(), c.Message)
+	}
+	return conditionTrue(r, s, "Available"), nil
+}
+
+// JobComplete is satisfied once a Job has completed. It fails if the Job has
+// failed.
+func JobComplete(r k8s.Resource) (bool, error) {
+	s, err := statusOf(r)
+	if err != nil {
+		return false, err
+	}
+	if c, ok := s.condition("Failed"); ok && c.Status == "True" {
+		return false, fmt.Errorf("job %s failed: %s: %s", r.GetMetadata().GetName(), c.Reason, c.Message)
+	}
+	return conditionTrue(r, s, "Complete"), nil
+}
+
+// CRDEstablished is satisfied once the API server serves the resource defined
+// by a CustomResourceDefinition. It fails if the CustomResourceDefinition's
+// names conflict with another resource.
+func CRDEstablished(r k8s.Resource) (bool, error) {
+	s, err := statusOf(r)
+	if err != nil {
+		return false, err
+	}
+	if c, ok := s.condition("NamesAccepted"); ok && c.Status == "False" {
+		return false, fmt.Errorf("names of CustomResourceDefinition %s weren't accepted: %s: %s", r.GetMetadata().GetName(), c.Reason, c.Message)
+	}
+	return conditionTrue(r, s, "Established"), nil
+}
@@ -0,0 +1,347 @@
+package wait
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	apiextensionsv1beta1 "github.com/ericchiang/k8s/apis/apiextensions/v1beta1"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+	batchv1 "github.com/ericchiang/k8s/apis/batch/v1"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/fake"
+)
+
+// watchNotifier signals each time a watch has been established, so tests can
+// modify resources once For is watching them.
+type watchNotifier struct {
+	*k8s.Client
+	watching chan struct{}
+}
+
+func (c *watchNotifier) Watch(ctx context.Context, namespace string, r k8s.Resource, options ...k8s.Option) (*k8s.Watcher, error) {
+	w, err := c.Client.Watch(ctx, namespace, r, options...)
+	if err == nil {
+		c.watching <- struct{}{}
+	}
+	return w, err
+}
+
+func newPod(conditions ...*corev1.PodCondition) *corev1.Pod {
+	return &corev1.Pod{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-pod"),
+			Namespace: k8s.String("default"),
+		},
+		Status: &corev1.PodStatus{Conditions: conditions},
+	}
+}
+
+func podCondition(conditionType, status string) *corev1.PodCondition {
+	return &corev1.PodCondition{Type: k8s.String(conditionType), Status: k8s.String(status)}
+}
+
+func TestFor(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := &watchNotifier{srv.Client(), make(chan struct{}, 1)}
+	ctx := context.Background()
+
+	pod := newPod(podCondition("Ready", "False"))
+	if err := client.Create(ctx, pod); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	updated := make(chan error, 1)
+	go func() {
+		<-client.watching
+		pod := newPod(podCondition("Ready", "True"))
+		updated <- client.Update(ctx, pod)
+	}()
+
+	got := newPod()
+	if err := For(ctx, client, got, PodReady, 10*time.Second); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if err := <-updated; err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if c := got.Status.Conditions; len(c) != 1 || c[0].GetStatus() != "True" {
+		t.Errorf("expected pod to be updated with the ready pod, got %v", got.Status)
+	}
+
+	// Already satisfied, no watch is needed.
+	if err := For(ctx, client, newPod(), PodReady, 10*time.Second); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	select {
+	case <-client.watching:
+		t.Errorf("unexpected watch")
+	default:
+	}
+
+	// Not satisfied before the timeout.
+	err := For(ctx, client, newPod(), ConditionTrue("PodScheduled"), 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
+
+// updateAfterGet makes the pod ready after the first get, before the watch
+// following it is established.
+type updateAfterGet struct {
+	*k8s.Client
+	once sync.Once
+}
+
+func (c *updateAfterGet) Get(ctx context.Context, namespace, name string, r k8s.Resource, options ...k8s.Option) error {
+	if err := c.Client.Get(ctx, namespace, name, r, options...); err != nil {
+		return err
+	}
+	var err error
+	c.once.Do(func() {
+		err = c.Client.Update(ctx, newPod(podCondition("Ready", "True")))
+	})
+	return err
+}
+
+func TestForMissedUpdate(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := &updateAfterGet{Client: srv.Client()}
+	ctx := context.Background()
+
+	if err := client.Create(ctx, newPod(podCondition("Ready", "False"))); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// The watch starts from the resource version of the get, so the update
+	// is delivered by it.
+	if err := For(ctx, client, newPod(), PodReady, 10*time.Second); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+}
+
+func TestForCreate(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := &watchNotifier{srv.Client(), make(chan struct{}, 1)}
+	ctx := context.Background()
+
+	crd := &apiextensionsv1beta1.CustomResourceDefinition{
+		Metadata: &metav1.ObjectMeta{Name: k8s.String("widgets.example.com")},
+	}
+	created := make(chan error, 1)
+	go func() {
+		<-client.watching
+		crd := &apiextensionsv1beta1.CustomResourceDefinition{
+			Metadata: &metav1.ObjectMeta{Name: k8s.String("widgets.example.com")},
+			Status: &apiextensionsv1beta1.CustomResourceDefinitionStatus{
+				Conditions: []*apiextensionsv1beta1.CustomResourceDefinitionCondition{
+					{Type: k8s.String("Established"), Status: k8s.String("True")},
+				},
+			},
+		}
+		created <- client.Create(ctx, crd)
+	}()
+
+	if err := For(ctx, client, crd, CRDEstablished, 10*time.Second); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if err := <-created; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if crd.Metadata.GetUid() == "" {
+		t.Errorf("expected crd to be updated with the created crd")
+	}
+}
+
+func TestForError(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	job := &batchv1.Job{
+		Metadata: &metav1.ObjectMeta{
+			Name:      k8s.String("my-job"),
+			Namespace: k8s.String("default"),
+		},
+		Status: &batchv1.JobStatus{
+			Conditions: []*batchv1.JobCondition{{
+				Type:    k8s.String("Failed"),
+				Status:  k8s.String("True"),
+				Reason:  k8s.String("BackoffLimitExceeded"),
+				Message: k8s.String("Job has reached the specified backoff limit"),
+			}},
+		},
+	}
+	if err := client.Create(ctx, job); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	err := For(ctx, client, job, JobComplete, 10*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "BackoffLimitExceeded") {
+		t.Errorf("expected job failure, got %v", err)
+	}
+}
+
+func TestForWatchErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		// Body of watch responses.
+		watch   string
+		timeout time.Duration
+		// Expected error, empty if the wait should time out.
+		wantErr string
+		// Bounds of the number of watches made before timing out.
+		minWatches, maxWatches int
+	}{
+		{
+			name:    "forbidden",
+			watch:   `{"type":"ERROR","object":{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","message":"watch is forbidden","code":403}}` + "\n",
+			timeout: 5 * time.Second,
+			wantErr: "watch is forbidden",
+		},
+		{
+			// Retries back off, starting at 100ms.
+			name:       "gone",
+			watch:      `{"type":"ERROR","object":{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Expired","code":410}}` + "\n",
+			timeout:    500 * time.Millisecond,
+			minWatches: 2,
+			maxWatches: 5,
+		},
+		{
+			name:       "closed",
+			timeout:    500 * time.Millisecond,
+			minWatches: 2,
+			maxWatches: 5,
+		},
+		{
+			// Watches which deliver an event don't back off.
+			name:       "progress",
+			watch:      `{"type":"MODIFIED","object":{"metadata":{"name":"my-pod","namespace":"default","resourceVersion":"2"}}}` + "\n",
+			timeout:    500 * time.Millisecond,
+			minWatches: 4,
+			maxWatches: 6,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				watches int
+			)
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Query().Get("watch") == "true" {
+					mu.Lock()
+					watches++
+					mu.Unlock()
+					w.Write([]byte(test.watch))
+					return
+				}
+				w.Write([]byte(`{"metadata":{"name":"my-pod","namespace":"default","resourceVersion":"1"}}`))
+			}))
+			defer s.Close()
+
+			client := &k8s.Client{Endpoint: s.URL, Client: s.Client(), ContentType: k8s.ContentTypeJSON}
+			err := For(context.Background(), client, newPod(), PodReady, test.timeout)
+			if err == nil {
+				t.Fatalf("expected wait to fail")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if test.wantErr != "" {
+				if !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("expected error %q, got %v", test.wantErr, err)
+				}
+				if watches != 1 {
+					t.Errorf("expected watch not to be retried, got %d watches", watches)
+				}
+				return
+			}
+			if watches < test.minWatches || watches > test.maxWatches {
+				t.Errorf("expected %d to %d watches, got %d", test.minWatches, test.maxWatches, watches)
+			}
+		})
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	deployment := func(generation, observed int64, conditions ...*appsv1.DeploymentCondition) k8s.Resource {
+		return &appsv1.Deployment{
+			Metadata: &metav1.ObjectMeta{Name: k8s.String("my-deployment"), Generation: &generation},
+			Status: &appsv1.DeploymentStatus{
+				ObservedGeneration: &observed,
+				Conditions:         conditions,
+			},
+		}
+	}
+	deploymentCondition := func(conditionType, status, reason string) *appsv1.DeploymentCondition {
+		return &appsv1.DeploymentCondition{
+			Type:   k8s.String(conditionType),
+			Status: k8s.String(status),
+			Reason: k8s.String(reason),
+		}
+	}
+	unstructured := func(data string) k8s.Resource {
+		u := new(k8s.Unstructured)
+		if err := u.UnmarshalJSON([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	tests := []struct {
+		name      string
+		predicate Predicate
+		r         k8s.Resource
+		want      bool
+		wantErr   bool
+	}{
+		{"pod ready", PodReady, newPod(podCondition("Ready", "True")), true, false},
+		{"pod not ready", PodReady, newPod(podCondition("Ready", "False")), false, false},
+		{"pod no conditions", PodReady, newPod(), false, false},
+		{"pod failed", PodReady, &corev1.Pod{
+			Metadata: &metav1.ObjectMeta{Name: k8s.String("my-pod")},
+			Status:   &corev1.PodStatus{Phase: k8s.String("Failed")},
+		}, false, true},
+		{"deployment available", DeploymentAvailable, deployment(2, 2, deploymentCondition("Available", "True", "MinimumReplicasAvailable")), true, false},
+		{"deployment update not observed", DeploymentAvailable, deployment(3, 2, deploymentCondition("Available", "True", "MinimumReplicasAvailable")), false, false},
+		{"deployment deadline exceeded", DeploymentAvailable, deployment(2, 2, deploymentCondition("Progressing", "False", "ProgressDeadlineExceeded")), false, true},
+		{"unstructured job complete", JobComplete, unstructured(`{
+			"apiVersion": "batch/v1",
+			"kind": "Job",
+			"metadata": {"name": "my-job"},
+			"status": {"conditions": [{"type": "Complete", "status": "True"}]}
+		}`), true, false},
+		{"unstructured custom condition", ConditionTrue("Synced"), unstructured(`{
+			"apiVersion": "example.com/v1",
+			"kind": "Widget",
+			"metadata": {"name": "my-widget", "generation": 2},
+			"status": {"observedGeneration": 2, "conditions": [{"type": "Synced", "status": "True"}]}
+		}`), true, false},
+		{"node ready", NodeReady, &corev1.Node{
+			Metadata: &metav1.ObjectMeta{Name: k8s.String("my-node")},
+			Status: &corev1.NodeStatus{
+				Conditions: []*corev1.NodeCondition{{Type: k8s.String("Ready"), Status: k8s.String("True")}},
+			},
+		}, true, false},
+	}
+	for _, test := range tests {
+		got, err := test.predicate(test.r)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.wantErr, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
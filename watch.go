@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 
 	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
 	"github.com/ericchiang/k8s/runtime"
@@ -181,20 +182,41 @@ func parseUnknown(b []byte) (*runtime.Unknown, error) {
 //		}
 //
 func (c *Client) Watch(ctx context.Context, namespace string, r Resource, options ...Option) (*Watcher, error) {
-	url, err := resourceWatchURL(c.Endpoint, namespace, r, options...)
+	url, err := resourceWatchURL(c.Endpoint, namespace, r, readOptions(options)...)
 	if err != nil {
 		return nil, err
 	}
 
-	ct := contentTypeFor(r)
+	ct := c.contentTypeFor(r, options)
+	resp, err := c.watch(ctx, url, ct)
+	if ct == contentTypePB && unsupportedContentType(err) {
+		ct = contentTypeJSON
+		resp, err = c.watch(ctx, url, ct)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ct == contentTypePB {
+		return &Watcher{&watcherPB{r: resp.Body}}, nil
+	}
 
+	return &Watcher{&watcherJSON{
+		d: json.NewDecoder(resp.Body),
+		c: resp.Body,
+	}}, nil
+}
+
+// watch opens a watch stream. The caller is responsible for closing the
+// response body.
+func (c *Client) watch(ctx context.Context, url, contentType string) (*http.Response, error) {
 	req, err := c.newRequest(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Accept", ct)
+	req.Header.Set("Accept", contentType)
 
-	resp, err := c.client().Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return nil, err
 	}
@@ -207,13 +229,5 @@ func (c *Client) Watch(ctx context.Context, namespace string, r Resource, option
 		}
 		return nil, newAPIError(resp.Header.Get("Content-Type"), resp.StatusCode, body)
 	}
-
-	if ct == contentTypePB {
-		return &Watcher{&watcherPB{r: resp.Body}}, nil
-	}
-
-	return &Watcher{&watcherJSON{
-		d: json.NewDecoder(resp.Body),
-		c: resp.Body,
-	}}, nil
+	return resp, nil
 }